@@ -0,0 +1,309 @@
+package facet
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/palette/brewer"
+)
+
+// ----------------------------------------------------------------------------
+// ColorList
+
+// ColorList is a palette.ColorMap built from an ordered list of colors
+// which are spread evenly over [Min, Max]. If Interpolate is set At blends
+// linearly between adjacent colors (suitable for sequential and diverging
+// color schemes), otherwise At returns the nearest color of the list and
+// Palette returns the first colors of the list (suitable for qualitative
+// color schemes).
+type ColorList struct {
+	List        []color.Color
+	Interpolate bool
+
+	min, max, alpha float64
+}
+
+var _ palette.ColorMap = (*ColorList)(nil)
+
+// NewColorList returns a ColorList for the given colors mapping [0, 1].
+func NewColorList(colors []color.Color, interpolate bool) *ColorList {
+	return &ColorList{
+		List:        colors,
+		Interpolate: interpolate,
+		min:         0,
+		max:         1,
+		alpha:       1,
+	}
+}
+
+// At returns the color mapped for x.
+func (cl *ColorList) At(x float64) (color.Color, error) {
+	switch {
+	case len(cl.List) == 0:
+		return nil, fmt.Errorf("facet: empty color list")
+	case cl.max <= cl.min:
+		return nil, fmt.Errorf("facet: illegal color list range [%g,%g]", cl.min, cl.max)
+	case math.IsNaN(x):
+		return nil, palette.ErrNaN
+	case x < cl.min:
+		return nil, palette.ErrUnderflow
+	case x > cl.max:
+		return nil, palette.ErrOverflow
+	}
+
+	n := len(cl.List)
+	t := float64(n-1) * (x - cl.min) / (cl.max - cl.min)
+	if !cl.Interpolate || n == 1 {
+		return cl.withAlpha(cl.List[int(math.Round(t))]), nil
+	}
+
+	i := int(math.Floor(t))
+	if i >= n-1 {
+		i = n - 2
+	}
+	f := t - float64(i)
+	a := color.NRGBAModel.Convert(cl.List[i]).(color.NRGBA)
+	b := color.NRGBAModel.Convert(cl.List[i+1]).(color.NRGBA)
+	blend := func(u, v uint8) uint8 {
+		return uint8(math.Round((1-f)*float64(u) + f*float64(v)))
+	}
+	return cl.withAlpha(color.NRGBA{
+		R: blend(a.R, b.R),
+		G: blend(a.G, b.G),
+		B: blend(a.B, b.B),
+		A: blend(a.A, b.A),
+	}), nil
+}
+
+func (cl *ColorList) withAlpha(c color.Color) color.Color {
+	if cl.alpha == 1 {
+		return c
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = uint8(math.Round(float64(n.A) * cl.alpha))
+	return n
+}
+
+// Max returns the current maximum value of the ColorMap.
+func (cl *ColorList) Max() float64 { return cl.max }
+
+// SetMax sets the maximum value of the ColorMap.
+func (cl *ColorList) SetMax(max float64) { cl.max = max }
+
+// Min returns the current minimum value of the ColorMap.
+func (cl *ColorList) Min() float64 { return cl.min }
+
+// SetMin sets the minimum value of the ColorMap.
+func (cl *ColorList) SetMin(min float64) { cl.min = min }
+
+// Alpha returns the opacity value of the ColorMap.
+func (cl *ColorList) Alpha() float64 { return cl.alpha }
+
+// SetAlpha sets the opacity value of the ColorMap. It panics if alpha
+// is not between zero and one.
+func (cl *ColorList) SetAlpha(alpha float64) {
+	if alpha < 0 || alpha > 1 {
+		panic(alpha)
+	}
+	cl.alpha = alpha
+}
+
+// Palette returns a palette of colors many colors. If cl interpolates the
+// colors are evenly spread over the list, i.e. they are the colors At
+// returns for evenly spaced values. Otherwise the palette consists of the
+// first colors of the list, repeating the list if it is too short.
+func (cl *ColorList) Palette(colors int) palette.Palette {
+	if cl.Interpolate || len(cl.List) == 0 {
+		return evenPalette(cl, colors)
+	}
+	list := make(colorSlice, colors)
+	for i := range list {
+		list[i] = cl.withAlpha(cl.List[i%len(cl.List)])
+	}
+	return list
+}
+
+// evenPalette samples cm at n evenly spaced values.
+func evenPalette(cm palette.ColorMap, n int) palette.Palette {
+	colors := make(colorSlice, n)
+	min, max := cm.Min(), cm.Max()
+	for i := range colors {
+		x := min
+		if n > 1 {
+			x += (max - min) * float64(i) / float64(n-1)
+		}
+		colors[i], _ = cm.At(x)
+	}
+	return colors
+}
+
+// discretePalette returns the colors of the levels of the discrete scale s:
+// The levels in s.Range get the colors of cm's palette in order. It
+// returns nil if s is not discrete.
+func discretePalette(cm palette.ColorMap, s *Scale) []color.Color {
+	if s.ScaleType != Discrete || math.IsNaN(s.Range.Min) || math.IsNaN(s.Range.Max) {
+		return nil
+	}
+	first, last := math.Ceil(s.Range.Min), math.Floor(s.Range.Max)
+	if last < first {
+		return nil
+	}
+	return cm.Palette(int(last-first) + 1).Colors()
+}
+
+// discreteColor returns the color of level v of a discrete scale with
+// range r from the colors of its levels.
+func discreteColor(colors []color.Color, r Interval, v float64) (color.Color, bool) {
+	k := level(v) - int(math.Ceil(r.Min))
+	if k < 0 || k >= len(colors) {
+		return nil, false
+	}
+	return colors[k], true
+}
+
+type colorSlice []color.Color
+
+func (cs colorSlice) Colors() []color.Color { return cs }
+
+// ----------------------------------------------------------------------------
+// ColorBrewer, Okabe-Ito and viridis
+
+// Brewer returns the ColorBrewer color scheme name (e.g. "Set1", "Blues"
+// or "RdBu") as a ColorMap. The largest available variant of the scheme
+// is used. Sequential and diverging schemes are interpolated, qualitative
+// ones are not.
+func Brewer(name string) (palette.ColorMap, error) {
+	if q, ok := brewer.QualitativePalettes[name]; ok {
+		return NewColorList(largestBrewer(q), false), nil
+	}
+	if s, ok := brewer.SequentialPalettes[name]; ok {
+		return NewColorList(largestBrewer(s), true), nil
+	}
+	if d, ok := brewer.DivergingPalettes[name]; ok {
+		return NewColorList(largestBrewer(d), true), nil
+	}
+	return nil, fmt.Errorf("facet: unknown ColorBrewer palette %q", name)
+}
+
+// largestBrewer returns the colors of the largest variant of scheme which
+// must be a brewer.Qualitative, brewer.Sequential or brewer.Diverging.
+func largestBrewer(scheme interface{}) []color.Color {
+	var colors []color.Color
+	pick := func(n int, p palette.Palette) {
+		if n > len(colors) {
+			colors = p.Colors()
+		}
+	}
+	switch s := scheme.(type) {
+	case brewer.Qualitative:
+		for n, p := range s {
+			pick(n, p)
+		}
+	case brewer.Sequential:
+		for n, p := range s {
+			pick(n, p)
+		}
+	case brewer.Diverging:
+		for n, p := range s {
+			pick(n, p)
+		}
+	}
+	return colors
+}
+
+// OkabeItoColors is the color-blind safe qualitative palette proposed by
+// Masataka Okabe and Kei Ito.
+var OkabeItoColors = []color.Color{
+	hexColor(0xe69f00), // orange
+	hexColor(0x56b4e9), // sky blue
+	hexColor(0x009e73), // bluish green
+	hexColor(0xf0e442), // yellow
+	hexColor(0x0072b2), // blue
+	hexColor(0xd55e00), // vermillion
+	hexColor(0xcc79a7), // reddish purple
+	hexColor(0x000000), // black
+}
+
+// OkabeIto returns the Okabe-Ito palette as a qualitative ColorMap.
+func OkabeIto() palette.ColorMap {
+	return NewColorList(OkabeItoColors, false)
+}
+
+// The perceptually uniform color maps from matplotlib are approximated by
+// interpolating between evenly spaced samples.
+var (
+	viridisColors = []color.Color{
+		hexColor(0x440154), hexColor(0x472d7b), hexColor(0x3b528b),
+		hexColor(0x2c728e), hexColor(0x21908c), hexColor(0x27ad81),
+		hexColor(0x5dc863), hexColor(0xaadc32), hexColor(0xfde725),
+	}
+	magmaColors = []color.Color{
+		hexColor(0x000004), hexColor(0x1d1147), hexColor(0x51127c),
+		hexColor(0x822681), hexColor(0xb63679), hexColor(0xe65164),
+		hexColor(0xfb8861), hexColor(0xfec287), hexColor(0xfcfdbf),
+	}
+	infernoColors = []color.Color{
+		hexColor(0x000004), hexColor(0x1f0c48), hexColor(0x550f6d),
+		hexColor(0x88226a), hexColor(0xba3655), hexColor(0xe35932),
+		hexColor(0xf98c0a), hexColor(0xf9c932), hexColor(0xfcffa4),
+	}
+	cividisColors = []color.Color{
+		hexColor(0x00204d), hexColor(0x414d6b), hexColor(0x7c7b78),
+		hexColor(0xbcaf6f), hexColor(0xffea46),
+	}
+)
+
+// Viridis returns matplotlib's viridis color map.
+func Viridis() palette.ColorMap { return NewColorList(viridisColors, true) }
+
+// Magma returns matplotlib's magma color map.
+func Magma() palette.ColorMap { return NewColorList(magmaColors, true) }
+
+// Inferno returns matplotlib's inferno color map.
+func Inferno() palette.ColorMap { return NewColorList(infernoColors, true) }
+
+// Cividis returns the color vision deficiency optimized cividis color map.
+func Cividis() palette.ColorMap { return NewColorList(cividisColors, true) }
+
+func hexColor(rgb uint32) color.Color {
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}
+}
+
+// ----------------------------------------------------------------------------
+// Helpers to set the ColorMap and FillMap of a Plot.
+
+// SetColorMap sets p's ColorMap (or FillMap if fill is true) to cm.
+func (p *Plot) SetColorMap(cm palette.ColorMap, fill bool) {
+	if fill {
+		p.FillMap = cm
+	} else {
+		p.ColorMap = cm
+	}
+}
+
+// UseBrewer sets p's ColorMap (or FillMap) to the ColorBrewer scheme name.
+func (p *Plot) UseBrewer(name string, fill bool) error {
+	cm, err := Brewer(name)
+	if err != nil {
+		return err
+	}
+	p.SetColorMap(cm, fill)
+	return nil
+}
+
+// UseOkabeIto sets p's ColorMap (or FillMap) to the Okabe-Ito palette.
+func (p *Plot) UseOkabeIto(fill bool) { p.SetColorMap(OkabeIto(), fill) }
+
+// UseViridis sets p's ColorMap (or FillMap) to viridis.
+func (p *Plot) UseViridis(fill bool) { p.SetColorMap(Viridis(), fill) }
+
+// UseMagma sets p's ColorMap (or FillMap) to magma.
+func (p *Plot) UseMagma(fill bool) { p.SetColorMap(Magma(), fill) }
+
+// UseInferno sets p's ColorMap (or FillMap) to inferno.
+func (p *Plot) UseInferno(fill bool) { p.SetColorMap(Inferno(), fill) }
+
+// UseCividis sets p's ColorMap (or FillMap) to cividis.
+func (p *Plot) UseCividis(fill bool) { p.SetColorMap(Cividis(), fill) }
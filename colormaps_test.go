@@ -0,0 +1,148 @@
+package facet
+
+import (
+	"image/color"
+	"testing"
+
+	"gonum.org/v1/plot/palette"
+)
+
+var colorListTests = []struct {
+	interpolate bool
+	x           float64
+	want        color.NRGBA
+}{
+	{false, 0, color.NRGBA{0, 0, 0, 0xff}},
+	{false, 0.2, color.NRGBA{0, 0, 0, 0xff}},
+	{false, 0.3, color.NRGBA{0x80, 0x80, 0x80, 0xff}},
+	{false, 1, color.NRGBA{0xff, 0xff, 0xff, 0xff}},
+	{true, 0, color.NRGBA{0, 0, 0, 0xff}},
+	{true, 0.25, color.NRGBA{0x40, 0x40, 0x40, 0xff}},
+	{true, 0.75, color.NRGBA{0xc0, 0xc0, 0xc0, 0xff}},
+	{true, 1, color.NRGBA{0xff, 0xff, 0xff, 0xff}},
+}
+
+func TestColorList(t *testing.T) {
+	list := []color.Color{hexColor(0x000000), hexColor(0x808080), hexColor(0xffffff)}
+	for i, tc := range colorListTests {
+		cl := NewColorList(list, tc.interpolate)
+		got, err := cl.At(tc.x)
+		if err != nil {
+			t.Errorf("%d. At(%g) unexpected error %v", i, tc.x, err)
+			continue
+		}
+		if n := color.NRGBAModel.Convert(got).(color.NRGBA); n != tc.want {
+			t.Errorf("%d. At(%g)=%v, want %v", i, tc.x, n, tc.want)
+		}
+	}
+}
+
+func TestBrewer(t *testing.T) {
+	for _, name := range []string{"Set1", "Blues", "RdBu"} {
+		cm, err := Brewer(name)
+		if err != nil {
+			t.Errorf("Brewer(%q) unexpected error %v", name, err)
+			continue
+		}
+		if got := len(cm.Palette(5).Colors()); got != 5 {
+			t.Errorf("Brewer(%q) palette has %d colors, want 5", name, got)
+		}
+	}
+	if _, err := Brewer("NoSuchPalette"); err == nil {
+		t.Errorf("Brewer(NoSuchPalette) did not fail")
+	}
+}
+
+func TestColorListPalette(t *testing.T) {
+	okabeIto := OkabeIto().Palette(3).Colors()
+	for i, c := range okabeIto {
+		if c != OkabeItoColors[i] {
+			t.Errorf("OkabeIto color %d = %v, want %v", i, c, OkabeItoColors[i])
+		}
+	}
+	if got := OkabeIto().Palette(10).Colors()[9]; got != OkabeItoColors[1] {
+		t.Errorf("OkabeIto color 9 = %v, want %v", got, OkabeItoColors[1])
+	}
+
+	set1, _ := Brewer("Set1")
+	want := []color.Color{hexColor(0xe41a1c), hexColor(0x377eb8), hexColor(0x4daf4a)}
+	for i, c := range set1.Palette(3).Colors() {
+		if color.NRGBAModel.Convert(c) != want[i] {
+			t.Errorf("Set1 color %d = %v, want %v", i, c, want[i])
+		}
+	}
+
+	// Interpolating lists are spread over all colors.
+	viridis := Viridis().Palette(3).Colors()
+	if viridis[0] != viridisColors[0] || viridis[2] != viridisColors[8] {
+		t.Errorf("Viridis palette = %v", viridis)
+	}
+}
+
+func TestMapColorDiscrete(t *testing.T) {
+	p := NewSimplePlot()
+	p.ColorMap = OkabeIto()
+	p.Scales[ColorScale].ScaleType = Discrete
+	p.Scales[ColorScale].Range = Interval{1, 3}
+	for v := 1; v <= 3; v++ {
+		if got := p.MapColor(float64(v), false); got != OkabeItoColors[v-1] {
+			t.Errorf("MapColor(%d) = %v, want %v", v, got, OkabeItoColors[v-1])
+		}
+	}
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	p.Scales[ColorScale].NAColor = red
+	if got := p.MapColor(4, false); got != red {
+		t.Errorf("MapColor(4) = %v, want NA color", got)
+	}
+}
+
+// countingMap is a ColorMap which counts the calls to Palette, SetMin
+// and SetMax.
+type countingMap struct {
+	palette.ColorMap
+	palettes, sets int
+}
+
+func (m *countingMap) Palette(n int) palette.Palette {
+	m.palettes++
+	return m.ColorMap.Palette(n)
+}
+
+func (m *countingMap) SetMin(min float64) {
+	m.sets++
+	m.ColorMap.SetMin(min)
+}
+
+func (m *countingMap) SetMax(max float64) {
+	m.sets++
+	m.ColorMap.SetMax(max)
+}
+
+func TestMapColorDiscretePalette(t *testing.T) {
+	p := NewSimplePlot()
+	cm := &countingMap{ColorMap: OkabeIto()}
+	p.ColorMap = cm
+	s := p.Scales[ColorScale]
+	s.ScaleType = Discrete
+	s.Range = Interval{1, 3}
+
+	// Without Prepare the palette is computed on the fly.
+	if got := p.MapColor(2, false); got != OkabeItoColors[1] || cm.palettes != 1 {
+		t.Errorf("unprepared: MapColor(2) = %v with %d palettes", got, cm.palettes)
+	}
+
+	// Once prepared the palette is computed only once.
+	s.colors = discretePalette(cm, s)
+	cm.palettes = 0
+	for i := 0; i < 10; i++ {
+		for v := 1; v <= 3; v++ {
+			if got := p.MapColor(float64(v), false); got != OkabeItoColors[v-1] {
+				t.Errorf("MapColor(%d) = %v, want %v", v, got, OkabeItoColors[v-1])
+			}
+		}
+	}
+	if cm.palettes != 0 || cm.sets != 0 {
+		t.Errorf("MapColor computed %d palettes and changed min/max %d times",
+			cm.palettes, cm.sets)
+	}
+}
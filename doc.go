@@ -20,13 +20,21 @@
 // The other scales can be discrete or continouos.
 //
 // If a scale is used in a faceted plot a scale Guide is drawn to show how
-// the scales range maps to aesthetics. Guides for different scales are
-// combined iff:
+// the scales range maps to aesthetics. The Guide field of a Scale can
+// suppress the guide, force a guide type, title, label format and order
+// and force or prevent combining guides via a Merge key. Guides for
+// different scales without a Merge key are combined iff:
 //   1. The two scales are of the same kind (discrete, continuous, ...)
 //   2. The two scales have the same range.
 //   3. The two scales have the same Title or the Title is empty.
-//   4. The scales must use the same Ticker.
-//   5. Fill and Color can be combined if they use the same ColorMap or one is empty.
+//   4. The scales must use the same Ticker and produce the same labels.
+//   5. Either both or none of the scales are binned.
+//   6. Fill and Color can be combined if they use the same ColorMap or one is empty.
+//
+// Data values outside of the Range of a scale are handled according to
+// the scale's OOB policy: Censor (the default) drops them or maps them
+// to the scale's NA value, Squish clamps them to the Range and Keep maps
+// them as is and lets the canvas clip.
 //
 //
 // Faceted Plots and Grouping
@@ -34,4 +42,27 @@
 // There are one dimensional groupings and two-dimensional groupings
 // corresponding to facet_row, facet_column and facet_grid. Grouping
 // is done on discrete values (which may be an intervall).
+//
+//
+// Styles and Themes
+//
+// The appearance of a Plot is controlled by its Style. A Theme modifies
+// a Style and NewStyle applies themes on top of DefaultFacetStyle, e.g.
+//     p.Style = NewStyle(12, BWTheme)
+// Themes can be derived from other themes with Extend to override only
+// some elements. Styles can be saved as and loaded from JSON, see
+// Style.UnmarshalJSON. YAML is not supported directly: Convert YAML style
+// files to JSON (e.g. with a YAML package of your choice) before decoding.
+//
+// Fonts are selected by family and variant with MakeFont and
+// Style.SetFontFamily. Additional TrueType fonts can be registered with
+// RegisterFontFile and RegisterFontFamily. Text containing characters
+// missing in its font, e.g. CJK labels, is drawn in one of the
+// FallbackFonts.
+//
+// With MarkupTheme titles, strip and tick labels may contain markup for
+// super- and subscripts, bold and italic spans and Greek letters, e.g.
+// "CO_2 in \\i{µs}^{-1}", see MarkupTextHandler. Without it all text is
+// drawn as is. The space reserved for titles and strips grows with
+// multi-line text.
 package facet
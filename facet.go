@@ -8,13 +8,13 @@ import (
 	"log"
 	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/palette"
 	"gonum.org/v1/plot/palette/moreland"
-	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
 )
@@ -85,6 +85,29 @@ type Geom interface {
 	Draw(p *Panel)
 }
 
+// A PanelDataRanger is a Geom whose data range depends on the panel it is
+// drawn in, e.g. because it draws only in panels showing certain groups.
+type PanelDataRanger interface {
+	// PanelDataRange returns the ranges covered in panel p.
+	PanelDataRange(p *Panel) DataRanges
+}
+
+// panelDataRange returns the data range of geom in panel p.
+func panelDataRange(geom Geom, p *Panel) DataRanges {
+	if pdr, ok := geom.(PanelDataRanger); ok {
+		return pdr.PanelDataRange(p)
+	}
+	return geom.DataRange()
+}
+
+// A PositiveDataRanger is a Geom which can report the data range of its
+// positive x and y values. This is used for Logarithmic X and Y scales.
+type PositiveDataRanger interface {
+	// PositiveDataRange returns the X and Y ranges of the positive values
+	// and the number of non-positive x and y values.
+	PositiveDataRange() (dr DataRanges, nonPositiveX, nonPositiveY int)
+}
+
 // A FGeom is the geometrical representation of some faceted data.
 type FGeom interface {
 	// N returns the number of geoms in this data set.
@@ -159,6 +182,15 @@ type Plot struct {
 	// to a color
 	ColorMap, FillMap palette.ColorMap
 
+	// Manual contains optional explicit level to value tables which
+	// replace ColorMap, FillMap and the default shapes, dashes and sizes.
+	Manual ManualScales
+
+	// Annotations are drawn in every panel after the panel's Geoms.
+	// Only their X and Y data ranges are used to train the scales.
+	// (Annotations of a single panel are simply added to its Geoms.)
+	Annotations []Geom
+
 	// Style used during plotting. TODO: Keep here?
 	Style Style
 
@@ -192,8 +224,7 @@ func NewPlot(rows, cols int, freeX, freeY bool) *Plot {
 	for r := 0; r < plot.Rows; r++ {
 		plot.Panels[r] = make([]*Panel, cols)
 		for c := 0; c < plot.Cols; c++ {
-			plot.Panels[r][c] = new(Panel)
-			plot.Panels[r][c].Plot = plot
+			plot.Panels[r][c] = &Panel{Plot: plot, Row: r, Col: c}
 		}
 	}
 
@@ -231,14 +262,7 @@ func NewPlot(rows, cols int, freeX, freeY bool) *Plot {
 	plot.setScaleDefaults()
 
 	// The two color maps.
-	rainbow := &Rainbow{
-		Saturation: 0.9,
-		Value:      0.9,
-		StartHue:   0,
-		HueGap:     1.0 / 6.0,
-		alpha:      1,
-	}
-	plot.ColorMap = rainbow
+	plot.ColorMap = NewHCL()
 	plot.FillMap = moreland.ExtendedBlackBody()
 
 	return plot
@@ -258,7 +282,10 @@ func (p *Plot) setScaleDefaults() {
 		s.Trans = LinearTrans
 	}
 
-	// TODO: Trans for other scales.
+	// Alpha, Color and Fill are mapped linearly to [0, 1].
+	for _, a := range []int{AlphaScale, ColorScale, FillScale} {
+		p.Scales[a].Trans = LinearTrans
+	}
 
 	// The size scale normaly maps the size aestethics to area
 	// so use an sqrt transform and do not map 0 to visually nothing.
@@ -281,17 +308,81 @@ func (p *Plot) LearnDataRange() {
 		p.Scales[YScale] = p.YScales[row]
 		for col := 0; col < p.Cols; col++ {
 			p.Scales[XScale] = p.XScales[col]
-			for _, geom := range p.Panels[row][col].Geoms {
-				for s, r := range geom.DataRange() {
+			panel := p.Panels[row][col]
+			for _, geom := range panel.Geoms {
+				for s, r := range panelDataRange(geom, panel) {
 					p.Scales[s].UpdateData(r)
 				}
 			}
+			for _, geom := range p.Annotations {
+				dr := panelDataRange(geom, panel)
+				p.Scales[XScale].UpdateData(dr[XScale])
+				p.Scales[YScale].UpdateData(dr[YScale])
+			}
 		}
 	}
+	p.learnPositiveRange()
 	p.debugScales("After learning data ranges")
 
 }
 
+// learnPositiveRange restricts the Data range of Logarithmic X and Y scales
+// to the positive values and reports the number of non-positive values
+// which cannot be shown on these scales. Geoms must implement
+// PositiveDataRanger to take part.
+func (p *Plot) learnPositiveRange() {
+	positive := make(map[*Scale]Interval)
+	removed := make(map[*Scale]int)
+	for row := 0; row < p.Rows; row++ {
+		ys := p.YScales[row]
+		for col := 0; col < p.Cols; col++ {
+			xs := p.XScales[col]
+			if xs.ScaleType != Logarithmic && ys.ScaleType != Logarithmic {
+				continue
+			}
+			for _, geom := range p.Panels[row][col].Geoms {
+				pdr, ok := geom.(PositiveDataRanger)
+				if !ok {
+					continue
+				}
+				dr, nx, ny := pdr.PositiveDataRange()
+				for _, s := range []struct {
+					scale *Scale
+					r     Interval
+					n     int
+				}{{xs, dr[XScale], nx}, {ys, dr[YScale], ny}} {
+					if s.scale.ScaleType != Logarithmic {
+						continue
+					}
+					pos, ok := positive[s.scale]
+					if !ok {
+						pos = UnsetInterval
+					}
+					pos.Update(s.r.Min, s.r.Max)
+					positive[s.scale] = pos
+					removed[s.scale] += s.n
+				}
+			}
+		}
+	}
+
+	warn := func(name string, scales []*Scale) {
+		for i, s := range scales {
+			if n := removed[s]; n > 0 {
+				p.Warnf("Removed %d non-positive values from logarithmic %dth %s scale", n, i, name)
+				removed[s] = 0 // report shared scales once
+			}
+			if s.Data.Min <= 0 {
+				if pos, ok := positive[s]; ok {
+					s.Data = pos
+				}
+			}
+		}
+	}
+	warn("X", p.XScales)
+	warn("Y", p.YScales)
+}
+
 // Autoscale all scales based on the current Data range.
 func (p *Plot) Autoscale() {
 	p.applyToScales((*Scale).Autoscale)
@@ -327,11 +418,18 @@ func (f *Plot) applyToScales(m func(*Scale)) {
 		done[s] = true
 	}
 }
+
+// Warnf reports a warning to p's Messages (or to os.Stderr if Messages
+// is nil).
 func (p *Plot) Warnf(f string, args ...interface{}) {
 	if !strings.HasSuffix(f, "\n") {
 		f += "\n"
 	}
-	fmt.Fprintf(os.Stderr, f, args...)
+	w := p.Messages
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, f, args...)
 }
 
 func (p *Plot) debugScales(info string) {
@@ -353,15 +451,16 @@ func (p *Plot) debugScales(info string) {
 // DeDegenerateXandY makes sure the Limit intervall for all X and Y scales in p
 // are not degenerated: NaN and Inf are turned into -1 (Min) or +1 (Max)
 // degenerate intervalls of the form [a, a] are exapnded around a.
+// Logarithmic scales are kept positive.
 func (p *Plot) DeDegenerateXandY() {
 	// X- and Y-scales must not be unset or degenerate
 	for i, s := range p.XScales {
-		if s.Limit.Degenerate() {
+		if s.degenerateLimit() {
 			p.Warnf("Corrected degeneration of %dth X scale", i)
 		}
 	}
 	for i, s := range p.YScales {
-		if s.Limit.Degenerate() {
+		if s.degenerateLimit() {
 			p.Warnf("Corrected degeneration of %dth Y scale", i)
 		}
 	}
@@ -371,12 +470,16 @@ func (p *Plot) DeDegenerateXandY() {
 // Prepare learns the Data range of each scale, autoscales each scale's limit,
 // clears each scales's range and degenrated the X and Y scales.
 func (p *Plot) Prepare() {
+	p.applyToScales((*Scale).setupTrans)
 	p.LearnDataRange()
 	p.Autoscale()
 	p.DeDegenerateXandY()
 	p.fillRange()
+	p.applyToScales((*Scale).prepareBins)
 
 	p.setupColorAndSizeMaps() // TODO: this should go somewhere else
+	p.Scales[ColorScale].colors = discretePalette(p.ColorMap, p.Scales[ColorScale])
+	p.Scales[FillScale].colors = discretePalette(p.FillMap, p.Scales[FillScale])
 }
 
 func (p *Plot) setupColorAndSizeMaps() {
@@ -388,88 +491,128 @@ func (p *Plot) setupColorAndSizeMaps() {
 
 func (p *Plot) needGuides() bool {
 	for s := AlphaScale; s < numScales; s++ {
-		if p.Scales[s].HasData() {
+		if p.hasGuide(s) {
 			return true
 		}
 	}
 	return false
 }
 
-// Draw renders f to c.
+// Draw renders f to c. An error is returned if the axis or legend
+// positions in f.Style are invalid.
 func (f *Plot) Draw(c draw.Canvas) error {
 	debug.V("Drawing to canvas from ", c.Min.X, ",", c.Min.Y, " to ", c.Max.X, ",", c.Max.Y)
+	top, right, err := f.axisPositions()
+	if err != nil {
+		return err
+	}
+	if err := f.checkLegendPosition(); err != nil {
+		return err
+	}
+	if f.Style.Background != nil {
+		c.SetColor(f.Style.Background)
+		c.Fill(c.Rectangle.Path())
+	}
 	if f.Title != "" {
 		c.FillText(f.Style.Title, vg.Point{X: c.Center().X, Y: c.Max.Y}, f.Title)
-		c.Max.Y -= f.Style.TitleHeight
+		c.Max.Y -= fitText(f.Style.TitleHeight, f.Style.Title, f.Title)
 	}
 
-	if f.needGuides() {
-		// TODO: guides should be vertically centered.
-		guideWidth := f.Style.Legend.Discrete.Size * 3 // TODO: this 3 should be calculated or settable
-		gc := c
-		gc.Min.X = gc.Max.X - guideWidth
-
-		for _, combo := range f.combineGuides() {
-			gc.Max.Y = f.drawGuides(gc, combo)
-		}
-
-		c.Max.X -= guideWidth + f.Style.Legend.Discrete.Pad
-	}
+	guides := f.measureGuides(c)
+	c = f.placeLegend(c, guides)
 
 	var h1, h2, h3, h4 vg.Length
 	var w1, w2, w3, w4 vg.Length
 
 	// Determine various widths in main plot area.
 	if f.YScales[0].Title != "" {
-		w1 = f.Style.YAxis.TitleWidth
+		w1 = fitText(f.Style.YAxis.TitleWidth, f.Style.YAxis.Title, f.YScales[0].Title)
 	}
 	w2 = 30 // TODO: Dynamic
 	for _, rl := range f.RowLabels {
 		if rl != "" {
-			w4 = f.Style.VStrip.Width
-			break
+			w4 = maxLength(w4, fitText(f.Style.VStrip.Width, f.Style.VStrip.TextStyle, rl))
 		}
 	}
 	w3 = c.Max.X - c.Min.X - w1 - w2 - w4
 
+	// Setup the panel canvases, draw their background and draw the facet
+	// column and row labels. Repeated axes are drawn in the space between
+	// the panels.
+	padx, pady := f.Style.Panel.PadX, f.Style.Panel.PadY
+	if f.Style.YAxis.Repeat {
+		padx += w2
+	}
+	numCols, numRows := vg.Length(f.Cols), vg.Length(f.Rows)
+	width := (w3 - padx*(numCols-1)) / numCols
+
+	// Tick labels may use half the space between two panels.
+	var xmargin, ymargin vg.Length
+	if f.Cols > 1 {
+		xmargin = padx / 2
+	}
+
+	// The X ticks and their labels determine the height of the X axis.
+	xticks := make([][]plot.Tick, f.Cols)
+	xsty, ysty := f.Style.XAxis.MajorTick, f.Style.YAxis.MajorTick
+	xlabel := f.xTickLabelStyle(top)
+	for c, s := range f.XScales {
+		xticks[c] = s.axisTicks(width, xmargin, xsty.Number, xsty.Label.Font, false)
+		f.thinXLabels(s, xticks[c], width, xlabel)
+	}
+
 	// Determine various heights in main plot area.
 	if f.XScales[0].Title != "" {
-		h1 = f.Style.XAxis.TitleHeight
+		h1 = fitText(f.Style.XAxis.TitleHeight, f.Style.XAxis.Title, f.XScales[0].Title)
 	}
-	h2 = 20 // Tics and tic labels. TODO: calculate from style
+	h2 = f.xTickHeight(xticks, xlabel)
 	for _, cl := range f.ColLabels {
 		if cl != "" {
-			h4 = f.Style.HStrip.Height
-			break
+			h4 = maxLength(h4, fitText(f.Style.HStrip.Height, f.Style.HStrip.TextStyle, cl))
 		}
 	}
 	h3 = c.Max.Y - c.Min.Y - h1 - h2 - h4
+	if f.Style.XAxis.Repeat {
+		pady += h2
+	}
+	height := (h3 - pady*(numRows-1)) / numRows
+	if f.Rows > 1 {
+		ymargin = pady / 2
+	}
+
+	// The panels are framed by the axes, the strips and the axis titles:
+	// (left, bottom) is the lower left corner of the area covered by all
+	// panels.
+	left, bottom := c.Min.X+w1+w2, c.Min.Y+h1+h2
+	if right {
+		left = c.Min.X
+	}
+	if top {
+		bottom = c.Min.Y
+	}
 
 	// Draw the X and Y axis titles
-	c.FillText(f.Style.XAxis.Title, vg.Point{X: c.Min.X + w1 + w2 + w3/2, Y: c.Min.Y}, f.XScales[0].Title)
-	c.FillText(f.Style.YAxis.Title, vg.Point{X: c.Min.X, Y: c.Min.Y + h1 + h2 + h3/2}, f.YScales[0].Title)
+	if top {
+		c.FillText(f.Style.XAxis.Title, vg.Point{X: left + w3/2, Y: c.Max.Y - h1}, f.XScales[0].Title)
+	} else {
+		c.FillText(f.Style.XAxis.Title, vg.Point{X: left + w3/2, Y: c.Min.Y}, f.XScales[0].Title)
+	}
+	if right {
+		c.FillText(f.Style.YAxis.Title, vg.Point{X: c.Max.X - w1, Y: bottom + h3/2}, f.YScales[0].Title)
+	} else {
+		c.FillText(f.Style.YAxis.Title, vg.Point{X: c.Min.X, Y: bottom + h3/2}, f.YScales[0].Title)
+	}
 
-	xticks := make([][]plot.Tick, f.Cols)
 	yticks := make([][]plot.Tick, f.Rows)
-	for c, s := range f.XScales {
-		xticks[c] = s.Trans.Ticker.Ticks(s.Limit.Min, s.Limit.Max)
-	}
 	for r, s := range f.YScales {
-		yticks[r] = s.Trans.Ticker.Ticks(s.Limit.Min, s.Limit.Max)
+		yticks[r] = s.axisTicks(height, ymargin, ysty.Number, ysty.Label.Font, true)
 	}
-
-	// Setup the panel canvases, draw their background and draw the facet
-	// column and row labels.
-	padx, pady := f.Style.Panel.PadX, f.Style.Panel.PadY
-	numCols, numRows := vg.Length(f.Cols), vg.Length(f.Rows)
-	width := (w3 - padx*(numCols-1)) / numCols
-	height := (h3 - pady*(numRows-1)) / numRows
 	havePanelTitle := f.havePanelTitle()
 
 	// Point (x0,y0) is the top-left corner of each panel
-	y0 := c.Max.Y - h4
+	y0 := bottom + h3
 	for row, panels := range f.Panels {
-		x0 := c.Min.X + w1 + w2
+		x0 := left
 
 		for col, panel := range panels {
 			if panel == nil {
@@ -483,8 +626,11 @@ func (f *Plot) Draw(c draw.Canvas) error {
 				cb := c
 				cb.Min.X = panel.Canvas.Min.X
 				cb.Min.Y = panel.Canvas.Max.Y
+				if top {
+					cb.Min.Y += h2
+				}
 				cb.Max.X = panel.Canvas.Max.X
-				cb.Max.Y = cb.Min.Y + w4
+				cb.Max.Y = cb.Min.Y + h4
 				cb.SetColor(f.Style.HStrip.Background)
 				cb.Fill(cb.Rectangle.Path())
 				cb.FillText(f.Style.HStrip.TextStyle, cb.Center(), f.ColLabels[col])
@@ -494,6 +640,9 @@ func (f *Plot) Draw(c draw.Canvas) error {
 		cb := c
 		panel := f.Panels[row][f.Cols-1]
 		cb.Min = panel.Canvas.Rectangle.Max
+		if right {
+			cb.Min.X += w2
+		}
 		cb.Max.X = cb.Min.X + w4
 		cb.Max.Y = panel.Canvas.Rectangle.Min.Y
 		cb.SetColor(f.Style.VStrip.Background)
@@ -509,58 +658,271 @@ func (f *Plot) Draw(c draw.Canvas) error {
 			for _, geom := range panel.Geoms {
 				geom.Draw(panel)
 			}
+			for _, geom := range f.Annotations {
+				geom.Draw(panel)
+			}
+			if visibleLine(f.Style.Panel.Border) {
+				panel.Canvas.SetLineStyle(f.Style.Panel.Border)
+				panel.Canvas.Stroke(panel.Canvas.Rectangle.Path())
+			}
 		}
 	}
 
-	// Draw the tics
-	for c, xtick := range xticks {
-		for _, tick := range xtick {
-			panel := f.Panels[f.Rows-1][c]
-			r := panel.MapXY(tick.Value, 0)
-			sty := f.Style.XAxis.MajorTick.LineStyle
-			length := f.Style.XAxis.MajorTick.Length
-			align := vg.Length(f.Style.XAxis.MajorTick.Align)
-			if tick.IsMinor() {
-				sty = f.Style.XAxis.MinorTick.LineStyle
-				length = f.Style.XAxis.MinorTick.Length
-				align = vg.Length(f.Style.XAxis.MinorTick.Align)
-			}
-			canvas := panel.Canvas
-			y0 := canvas.Min.Y
-			canvas.StrokeLine2(sty, r.X, y0+align*length, r.X, y0+(align-1)*length)
-			if tick.IsMinor() {
+	f.drawInsideLegend(c, guides)
+
+	// Draw the axes: Only along the outer panels unless repeated.
+	rowHeight := f.xLabelRowHeight(xticks, xlabel)
+	for row, panels := range f.Panels {
+		for col, panel := range panels {
+			if panel == nil {
 				continue
 			}
-			canvas.FillText(f.Style.XAxis.MajorTick.Label,
-				vg.Point{r.X, y0 - length}, tick.Label)
-		}
-	}
-	for r, ytick := range yticks {
-		for _, tick := range ytick {
-			panel := f.Panels[r][0]
-			r := panel.MapXY(0, tick.Value)
-			sty := f.Style.YAxis.MajorTick.LineStyle
-			length := f.Style.YAxis.MajorTick.Length
-			align := vg.Length(f.Style.YAxis.MajorTick.Align)
-			if tick.IsMinor() {
-				sty = f.Style.YAxis.MinorTick.LineStyle
-				length = f.Style.YAxis.MinorTick.Length
-				align = vg.Length(f.Style.YAxis.MinorTick.Align)
+			if f.Style.XAxis.Repeat || (top && row == 0) || (!top && row == f.Rows-1) {
+				f.drawXAxis(panel, xticks[col], xlabel, rowHeight, top)
 			}
-			canvas := panel.Canvas
-			x0 := canvas.Min.X
-			canvas.StrokeLine2(sty, x0+(align-1)*length, r.Y, x0+align*length, r.Y)
-			if tick.IsMinor() {
-				continue
+			if f.Style.YAxis.Repeat || (right && col == f.Cols-1) || (!right && col == 0) {
+				f.drawYAxis(panel, yticks[row], right)
 			}
-			canvas.FillText(f.Style.YAxis.MajorTick.Label,
-				vg.Point{x0 - length, r.Y}, tick.Label)
 		}
 	}
 
 	return nil
 }
 
+// axisPositions reports whether the X axis is drawn on top of the panels
+// and the Y axis right of them.
+func (f *Plot) axisPositions() (top, right bool, err error) {
+	switch f.Style.XAxis.Position {
+	case "", "bottom":
+	case "top":
+		top = true
+	default:
+		return false, false, fmt.Errorf("facet: unknown X axis position %q", f.Style.XAxis.Position)
+	}
+	switch f.Style.YAxis.Position {
+	case "", "left":
+	case "right":
+		right = true
+	default:
+		return false, false, fmt.Errorf("facet: unknown Y axis position %q", f.Style.YAxis.Position)
+	}
+	return top, right, nil
+}
+
+// Tick directions for the Align field of the major and minor tick styles
+// of the X and Y axis.
+const (
+	TicksOutside  = 0   // Ticks point away from the panel.
+	TicksCrossing = 0.5 // Ticks cross the panel border.
+	TicksInside   = 1   // Ticks point into the panel.
+)
+
+// drawXAxis draws the axis line, the ticks and the tick labels of the X
+// axis below (or above if top) panel. Labels are drawn in sty, staggered
+// labels rowHeight apart.
+func (f *Plot) drawXAxis(panel *Panel, ticks []plot.Tick, sty draw.TextStyle, rowHeight vg.Length, top bool) {
+	canvas := panel.Canvas
+	y0, out := canvas.Min.Y, vg.Length(-1)
+	if top {
+		y0, out = canvas.Max.Y, 1
+	}
+	if visibleLine(f.Style.XAxis.Line) {
+		canvas.StrokeLine2(f.Style.XAxis.Line, canvas.Min.X, y0, canvas.Max.X, y0)
+	}
+	row := 0
+	for _, tick := range ticks {
+		r := panel.MapXY(tick.Value, 0)
+		line := f.Style.XAxis.MajorTick.LineStyle
+		length := f.Style.XAxis.MajorTick.Length
+		align := vg.Length(f.Style.XAxis.MajorTick.Align)
+		if tick.IsMinor() {
+			line = f.Style.XAxis.MinorTick.LineStyle
+			length = f.Style.XAxis.MinorTick.Length
+			align = vg.Length(f.Style.XAxis.MinorTick.Align)
+		}
+		if visibleLine(line) && length > 0 {
+			canvas.StrokeLine2(line, r.X, y0-out*align*length, r.X, y0+out*(1-align)*length)
+		}
+		if tick.IsMinor() || tick.Label == "" {
+			continue
+		}
+		offset := length * (1 - align)
+		if offset < 0 {
+			offset = 0
+		}
+		canvas.FillText(sty,
+			vg.Point{r.X, y0 + out*(offset+vg.Length(row)*rowHeight)}, tick.Label)
+		if f.Style.XAxis.MajorTick.Stagger {
+			row = 1 - row
+		}
+	}
+}
+
+// drawYAxis draws the axis line, the ticks and the tick labels of the Y
+// axis left (or right) of panel.
+func (f *Plot) drawYAxis(panel *Panel, ticks []plot.Tick, right bool) {
+	canvas := panel.Canvas
+	x0, out := canvas.Min.X, vg.Length(-1)
+	sty := f.Style.YAxis.MajorTick.Label
+	if right {
+		x0, out = canvas.Max.X, 1
+		sty.XAlign = -1 - sty.XAlign
+	}
+	if visibleLine(f.Style.YAxis.Line) {
+		canvas.StrokeLine2(f.Style.YAxis.Line, x0, canvas.Min.Y, x0, canvas.Max.Y)
+	}
+	for _, tick := range ticks {
+		r := panel.MapXY(0, tick.Value)
+		line := f.Style.YAxis.MajorTick.LineStyle
+		length := f.Style.YAxis.MajorTick.Length
+		align := vg.Length(f.Style.YAxis.MajorTick.Align)
+		if tick.IsMinor() {
+			line = f.Style.YAxis.MinorTick.LineStyle
+			length = f.Style.YAxis.MinorTick.Length
+			align = vg.Length(f.Style.YAxis.MinorTick.Align)
+		}
+		if visibleLine(line) && length > 0 {
+			canvas.StrokeLine2(line, x0-out*align*length, r.Y, x0+out*(1-align)*length, r.Y)
+		}
+		if tick.IsMinor() || tick.Label == "" {
+			continue
+		}
+		offset := length * (1 - align)
+		if offset < 0 {
+			offset = 0
+		}
+		canvas.FillText(sty, vg.Point{x0 + out*offset, r.Y}, tick.Label)
+	}
+}
+
+// visibleLine reports whether lines drawn in sty are visible.
+func visibleLine(sty draw.LineStyle) bool {
+	return sty.Color != nil && sty.Width > 0
+}
+
+// xTickLabelStyle returns the text style of the X tick labels below (or
+// above if top) the panels: Rotated labels are anchored at their end next
+// to the tick.
+func (f *Plot) xTickLabelStyle(top bool) draw.TextStyle {
+	sty := f.Style.XAxis.MajorTick.Label
+	if top {
+		sty.YAlign = -1 - sty.YAlign
+	}
+	sin := math.Sin(sty.Rotation)
+	if math.Abs(sin) > 1e-6 {
+		sty.XAlign, sty.YAlign = draw.XRight, -0.5
+		if (sin < 0) != top {
+			sty.XAlign = draw.XLeft
+		}
+	}
+	return sty
+}
+
+// xLabelRowHeight returns the height of one row of X tick labels drawn
+// in style sty, i.e. the height of the highest (rotated) label.
+func (f *Plot) xLabelRowHeight(xticks [][]plot.Tick, sty draw.TextStyle) vg.Length {
+	var height vg.Length
+	for _, ticks := range xticks {
+		for _, tick := range ticks {
+			if tick.IsMinor() || tick.Label == "" {
+				continue
+			}
+			r := textRectangle(sty, tick.Label)
+			if h := r.Max.Y - r.Min.Y; h > height {
+				height = h
+			}
+		}
+	}
+	return height
+}
+
+// xTickHeight returns the height needed below the panels for the X ticks
+// and their (rotated and staggered) labels.
+func (f *Plot) xTickHeight(xticks [][]plot.Tick, sty draw.TextStyle) vg.Length {
+	rows := vg.Length(1)
+	if f.Style.XAxis.MajorTick.Stagger {
+		rows = 2
+	}
+	height := f.xLabelRowHeight(xticks, sty)
+	if height == 0 {
+		height = sty.Height("0")
+	}
+	return f.Style.XAxis.MajorTick.Length + rows*height + sty.Font.Size*0.3
+}
+
+// thinXLabels removes labels from the major ticks of the X scale s shown
+// on panels of the given width until the remaining labels drawn in style
+// sty do not overlap: Only every nth label is kept.
+func (f *Plot) thinXLabels(s *Scale, ticks []plot.Tick, width vg.Length, sty draw.TextStyle) {
+	var labeled []int
+	for i, tick := range ticks {
+		if !tick.IsMinor() && tick.Label != "" {
+			labeled = append(labeled, i)
+		}
+	}
+	if len(labeled) < 2 {
+		return
+	}
+	sort.Slice(labeled, func(i, j int) bool {
+		return ticks[labeled[i]].Value < ticks[labeled[j]].Value
+	})
+
+	// The labels as unrotated boxes placed at their tick.
+	unrotated := sty
+	unrotated.Rotation = 0
+	sin, cos := math.Sincos(sty.Rotation)
+	gap := sty.Font.Size / 2
+	boxes := make([]vg.Rectangle, len(labeled))
+	pos := make([]vg.Length, len(labeled))
+	canvas := Interval{0, float64(width)}
+	for k, i := range labeled {
+		boxes[k] = textRectangle(unrotated, ticks[i].Label)
+		boxes[k].Max.X += gap
+		pos[k] = vg.Length(s.Trans.Trans(s.View(), canvas, ticks[i].Value))
+	}
+	rowHeight := f.xLabelRowHeight([][]plot.Tick{ticks}, sty)
+	stagger := f.Style.XAxis.MajorTick.Stagger
+
+	// overlap reports whether the labels a and b on the given rows overlap.
+	// The labels are parallel boxes which are compared in the frame of
+	// the rotated text.
+	overlap := func(a, b, rowA, rowB int) bool {
+		dx, dy := float64(pos[b]-pos[a]), -float64(vg.Length(rowB-rowA)*rowHeight)
+		tx := vg.Length(cos*dx + sin*dy)
+		ty := vg.Length(-sin*dx + cos*dy)
+		ra, rb := boxes[a], boxes[b]
+		return ra.Min.X < rb.Max.X+tx && rb.Min.X+tx < ra.Max.X &&
+			ra.Min.Y < rb.Max.Y+ty && rb.Min.Y+ty < ra.Max.Y
+	}
+	row := func(k int) int {
+		if stagger {
+			return k % 2
+		}
+		return 0
+	}
+
+	n := 1
+	for ; n < len(labeled); n++ {
+		ok := true
+		for k := 0; k+n < len(labeled) && ok; k += n {
+			for m := k + n; m < len(labeled) && m <= k+2*n; m += n {
+				if overlap(k, m, row(k/n), row(m/n)) {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok {
+			break
+		}
+	}
+	for k, i := range labeled {
+		if k%n != 0 {
+			ticks[i].Label = ""
+		}
+	}
+}
+
 func (p *Plot) havePanelTitle() bool {
 	for _, panels := range p.Panels {
 		for _, panel := range panels {
@@ -596,27 +958,28 @@ func (p *Plot) setupPanel(panel *Panel, row, col int, canvas draw.Canvas,
 	panel.Scales[YScale] = p.YScales[row]
 	panel.Canvas.SetColor(p.Style.Panel.Background)
 	panel.Canvas.Fill(panel.Canvas.Rectangle.Path())
-	if p.Style.Grid.Major.Color != nil {
-		for _, xtic := range xticks {
-			r := panel.MapXY(xtic.Value, 0)
-			sty := p.Style.Grid.Major
-			if xtic.IsMinor() {
-				sty = p.Style.Grid.Minor
-			}
+	for _, xtic := range xticks {
+		r := panel.MapXY(xtic.Value, 0)
+		sty := p.Style.Grid.Major
+		if xtic.IsMinor() {
+			sty = p.Style.Grid.Minor
+		}
+		if visibleLine(sty) {
 			panel.Canvas.StrokeLine2(sty,
 				r.X, y0, r.X, y0-height)
 		}
-		for _, ytic := range yticks {
-			r := panel.MapXY(0, ytic.Value)
-			sty := p.Style.Grid.Major
-			if ytic.IsMinor() {
-				sty = p.Style.Grid.Minor
-			}
+	}
+	for _, ytic := range yticks {
+		r := panel.MapXY(0, ytic.Value)
+		sty := p.Style.Grid.Major
+		if ytic.IsMinor() {
+			sty = p.Style.Grid.Minor
+		}
+		if visibleLine(sty) {
 			panel.Canvas.StrokeLine2(sty,
 				x0, r.Y, x0+width, r.Y)
 		}
 	}
-
 }
 
 func (p *Plot) drawStrip(c draw.Canvas, text string, min, max vg.Point, style draw.TextStyle) {
@@ -629,45 +992,107 @@ func (p *Plot) drawStrip(c draw.Canvas, text string, min, max vg.Point, style dr
 }
 
 // MapSize maps the data value s to a display length via f's size scale.
-// Values outside of of the range of the size scale are mapped to 0.
+// Censored and NaN values and levels missing in a manual Size table are
+// mapped to the scale's NAValue or to 0 if NAValue is NaN.
+// Identity size scales return v as is and manual Size tables are consulted
+// before the size scale.
 func (p *Plot) MapSize(v float64) vg.Length {
+	s := p.Scales[SizeScale]
+	if s.Identity {
+		return vg.Length(v)
+	}
+	na := vg.Length(0)
+	if !math.IsNaN(s.NAValue) {
+		na = vg.Length(s.NAValue)
+	}
+	if p.Manual.Size != nil {
+		if size, ok := p.Manual.Size[level(v)]; ok {
+			return size
+		}
+		return na
+	}
+
+	v, ok := s.ApplyOOB(v)
+	if !ok {
+		return na
+	}
 	min := 2.0
 	max := float64(0.5 * p.Style.Legend.Discrete.Size)
-	s := p.Scales[SizeScale]
 	t := s.Trans.Trans(s.Range, Interval{min, max}, v)
-
-	if !p.Scales[SizeScale].InRange(v) || math.IsNaN(t) {
+	if math.IsNaN(t) {
+		return na
+	}
+	if t < 0 {
 		return 0
 	}
 	return vg.Length(t)
 }
 
+// MapAlpha maps the data value v to an opacity in [0, 1] via p's Alpha
+// scale. Censored and NaN values are mapped to the scale's NAValue, a NaN
+// result means the value is not drawn.
+func (p *Plot) MapAlpha(v float64) float64 {
+	s := p.Scales[AlphaScale]
+	v, ok := s.ApplyOOB(v)
+	if !ok || !s.InRange(v) {
+		return s.NAValue
+	}
+	if a := s.Map(v); a >= 0 && a <= 1 {
+		return a
+	}
+	return s.NAValue
+}
+
 // MapColor maps the data value v to a color via p's ColorMap or
 // FillMap if fill is true.
-// Values outside if the relevant scale's intervall are mapped to
-// Gray50 (which is what ggplot2 does).
+// Censored and NaN values, levels missing in a manual Color or Fill table
+// and values the ColorMap fails to map are mapped to the relevant scale's
+// NAColor (Gray50 by default, which is what ggplot2 does).
+// Identity scales decode v via ColorValue and manual Color or Fill tables
+// are consulted before the ColorMap.
+// Diverging scales with a Midpoint are mapped via Scale.MapDiverging,
+// binned scales via Scale.MapBinned.
 func (p *Plot) MapColor(v float64, fill bool) color.Color {
-	scale, cm := p.Scales[ColorScale], p.ColorMap
+	scale, cm, manual := p.Scales[ColorScale], p.ColorMap, p.Manual.Color
 	if fill {
-		scale, cm = p.Scales[FillScale], p.FillMap
+		scale, cm, manual = p.Scales[FillScale], p.FillMap, p.Manual.Fill
 	}
-	if !scale.InRange(v) {
-		return color.Gray{0x7f}
+	if scale.Identity {
+		return valueColor(v)
 	}
-
-	t := scale.Map(v)
-	if math.IsNaN(t) {
-		return color.Gray{0x7f}
+	if manual != nil {
+		if col, ok := manual[level(v)]; ok {
+			return col
+		}
+		return scale.NAColor
+	}
+	v, ok := scale.ApplyOOB(v)
+	if !ok || !scale.InRange(v) {
+		return scale.NAColor
+	}
+	if scale.ScaleType == Discrete {
+		colors := scale.colors
+		if colors == nil {
+			colors = discretePalette(cm, scale)
+		}
+		if col, ok := discreteColor(colors, scale.Range, v); ok {
+			return col
+		}
+		return scale.NAColor
 	}
 
-	if t < 0 || t > 1 {
-		panic(fmt.Sprintf("MapColor(%g,%t), t=%f", v, fill, t))
+	t := scale.MapDiverging(v)
+	if scale.Binned {
+		t = scale.MapBinned(v)
+	}
+	if math.IsNaN(t) || t < 0 || t > 1 {
+		return scale.NAColor
 	}
 	cm.SetMin(0)
 	cm.SetMax(1)
 	col, err := cm.At(t)
 	if err != nil {
-		panic(fmt.Sprintf("MapColor(%g,%t), t=%f: %v", v, fill, t, err))
+		return scale.NAColor
 	}
 
 	return col
@@ -680,9 +1105,9 @@ func (f *Plot) combineGuides() [][]int {
 	combinations := [][]int{}
 	for j := AlphaScale; j < numScales; j++ {
 		debug.VV(scaleName[j], "data range", f.Scales[j].Data.Min, f.Scales[j].Data.Max, f.Scales[j].HasData())
-		if !f.Scales[j].HasData() {
-			debug.VV(scaleName[j], "has no data")
-			continue // This scale has no data, so no need to combine it.
+		if !f.hasGuide(j) {
+			debug.VV(scaleName[j], "has no data or is an identity scale")
+			continue // This scale has no guide, so no need to combine it.
 		}
 
 		combinable := false
@@ -713,6 +1138,15 @@ func (f *Plot) combineGuides() [][]int {
 func (p *Plot) canCombineScales(j, k int) bool {
 	s1, s2 := p.Scales[j], p.Scales[k]
 
+	// 0. An explicit Merge key or guide Type overrides the heuristics.
+	g1, g2 := s1.Guide, s2.Guide
+	if g1.Type != "" && g2.Type != "" && g1.Type != g2.Type {
+		return false
+	}
+	if g1.Merge != "" || g2.Merge != "" {
+		return g1.Merge == g2.Merge
+	}
+
 	// 1. The two scales are of the same kind (linear, discrete, time, ...)
 	if s1.ScaleType != s2.ScaleType {
 		debug.VVV("different type for", j, k)
@@ -727,11 +1161,12 @@ func (p *Plot) canCombineScales(j, k int) bool {
 	}
 
 	// 3. The two scales have the same Title or the Title is empty.
-	if s1.Title != s2.Title && s1.Title != "" && s2.Title != "" {
+	t1, t2 := p.guideTitle(j), p.guideTitle(k)
+	if t1 != t2 && t1 != "" && t2 != "" {
 		return false
 	}
 
-	// 4. The scales must use the same Ticker.
+	// 4. The scales must use the same Ticker and produce the same labels.
 	if s1.Ticker != nil && s2.Ticker != nil && s1.Ticker != s2.Ticker {
 		t1, t2 := s1.Ticker.Ticks(s1.Limit.Min, s1.Limit.Max), s2.Ticker.Ticks(s2.Limit.Min, s2.Limit.Max)
 		if len(t1) != len(t2) {
@@ -743,13 +1178,28 @@ func (p *Plot) canCombineScales(j, k int) bool {
 			}
 		}
 	}
+	if p.guideFormat(j) != nil || p.guideFormat(k) != nil {
+		for _, tick := range p.tickerFor([]int{j, k}).Ticks(s1.Limit.Min, s1.Limit.Max) {
+			if p.guideLabel(j, tick) != p.guideLabel(k, tick) {
+				return false
+			}
+		}
+	}
 
-	// 5. Fill and Color can be combined if they use the same ColorMap or one is empty.
+	// 5. Either both or none of the scales are binned.
+	if s1.Binned != s2.Binned {
+		return false
+	}
+
+	// 6. Fill and Color can be combined if they use the same ColorMap or one is empty.
 	if (j == FillScale && k == ColorScale) ||
 		(k == FillScale && j == ColorScale) {
 		if p.ColorMap != p.FillMap && p.ColorMap != nil && p.FillMap != nil {
 			return false
 		}
+		if !sameColorTable(p.Manual.Color, p.Manual.Fill) {
+			return false
+		}
 	}
 
 	return true
@@ -757,30 +1207,60 @@ func (p *Plot) canCombineScales(j, k int) bool {
 }
 
 // There are two major types of guides:
-//   A. Color guides for continuous scales drawn as a continuous "rainbow".
+//   A. Color bars for continuous Color, Fill and Alpha scales.
 //   B. Discrete guides where each label is shown as a small rectangle
 //      containing lines, symbols, etc.
-func (p *Plot) drawGuides(c draw.Canvas, scales []int) vg.Length {
+// The guide is drawn with its top left corner at the top left corner of c.
+// The returned rectangle is the area covered by the guide.
+func (p *Plot) drawGuides(c draw.Canvas, scales []int) vg.Rectangle {
+	top := vg.Point{X: c.Min.X, Y: c.Max.Y}
+	bbox := vg.Rectangle{Min: top, Max: top}
 	if title := p.titleFor(scales); title != "" {
-		pos := vg.Point{
-			X: c.Min.X,
-			Y: c.Max.Y,
-		}
-		c.FillText(p.Style.Legend.Title, pos, title)
-		c.Max.Y -= 2 * p.Style.Legend.Title.Font.Size
+		c.FillText(p.Style.Legend.Title, top, title)
+		c.Max.Y -= fitText(2*p.Style.Legend.Title.Font.Size, p.Style.Legend.Title, title)
+		bbox = unionRect(textRect(p.Style.Legend.Title, top, title), bbox)
+		bbox.Min.Y = minLength(bbox.Min.Y, c.Max.Y)
+	}
+
+	var r vg.Rectangle
+	if p.isColorBarGuide(scales) {
+		r = p.drawColorBar(c, scales)
+	} else {
+		r = p.drawDiscreteGuides(c, scales)
+	}
+	return unionRect(bbox, r)
+}
+
+// unionRect returns the smallest rectangle containing a and b.
+func unionRect(a, b vg.Rectangle) vg.Rectangle {
+	a.Min.X, a.Min.Y = minLength(a.Min.X, b.Min.X), minLength(a.Min.Y, b.Min.Y)
+	a.Max.X, a.Max.Y = maxLength(a.Max.X, b.Max.X), maxLength(a.Max.Y, b.Max.Y)
+	return a
+}
+
+// textRect returns the rectangle covered by txt drawn with sty at pt.
+func textRect(sty draw.TextStyle, pt vg.Point, txt string) vg.Rectangle {
+	r := textRectangle(sty, txt)
+	return vg.Rectangle{Min: r.Min.Add(pt), Max: r.Max.Add(pt)}
+}
+
+func minLength(a, b vg.Length) vg.Length {
+	if a < b {
+		return a
 	}
+	return b
+}
 
-	if p.isContinuousColorGuide(scales) {
-		s := p.Scales[scales[0]]
-		m := p.colorMapFor(scales)
-		return p.drawContinuousColorGuide(c, s, m)
+func maxLength(a, b vg.Length) vg.Length {
+	if a > b {
+		return a
 	}
-	return p.drawDiscreteGuides(c, scales)
+	return b
 }
 
 func (f *Plot) titleFor(scales []int) string {
 	for _, s := range scales {
-		if title := f.Scales[s].Title; title != "" {
+		if title := f.guideTitle(s); title != "" {
 			return title
 		}
 	}
@@ -793,6 +1273,8 @@ func (f *Plot) titleFor(scales []int) string {
 // TODO: Maybe Style and Symbol must be different kind of scales
 // as these cannot be anything than discrete as anything else cannont
 // be mapped to an aesthetics.
+// An explicit Ticker of one of the scales takes precedence, other scales
+// use the Ticker of their Trans like the color bar, e.g. LogTicks.
 func (f *Plot) tickerFor(scales []int) plot.Ticker {
 	for _, s := range scales {
 		if f.Scales[s].Ticker != nil {
@@ -803,6 +1285,11 @@ func (f *Plot) tickerFor(scales []int) plot.Ticker {
 		return DiscreteTicks{}
 
 	}
+	for _, s := range scales {
+		if t := f.Scales[s].ticker(); t != nil {
+			return t
+		}
+	}
 
 	return DefaultTicks(6)
 }
@@ -825,21 +1312,6 @@ func (DiscreteTicks) Ticks(min, max float64) []plot.Tick {
 	return ticks
 }
 
-// colorMapFor looks for a color map defined on one of the given scales.
-// Only Fill- and ColorScales are inspected.
-func (p *Plot) colorMapFor(scales []int) palette.ColorMap {
-	for _, s := range scales {
-		if s != FillScale && s != ColorScale {
-			continue
-		}
-		if s == ColorScale {
-			return p.ColorMap
-		}
-		return p.FillMap
-	}
-	return p.FillMap // TODO: panic ??
-}
-
 func (f *Plot) SizeMap() func(x float64) vg.Length {
 	if !f.Scales[SizeScale].HasData() {
 		return func(x float64) vg.Length { return 5 }
@@ -852,111 +1324,94 @@ func (f *Plot) SizeMap() func(x float64) vg.Length {
 	}
 }
 
-func (f *Plot) isContinuousColorGuide(scales []int) bool {
-	if f.Scales[scales[0]].ScaleType == Discrete {
-		return false
-	}
+// isColorBarGuide reports whether the guide for the combined scales is
+// drawn as a color bar, which is the case for continuous Color, Fill and
+// Alpha scales unless a different guide Type is requested.
+func (f *Plot) isColorBarGuide(scales []int) bool {
 	for _, s := range scales {
-		if s != FillScale && s != ColorScale {
+		if s != FillScale && s != ColorScale && s != AlphaScale {
 			return false
 		}
 	}
-	return true
+	switch f.guideType(scales) {
+	case "legend":
+		return false
+	case "colorbar":
+		return true
+	}
+	return f.Scales[scales[0]].ScaleType != Discrete
 }
 
-func (plot *Plot) drawDiscreteGuides(c draw.Canvas, scales []int) vg.Length {
+// drawDiscreteGuides draws one key per labeled tick. The keys are laid
+// out by layoutKeys and drawn by the layers mapping one of the scales
+// (see KeyDrawer).
+func (plot *Plot) drawDiscreteGuides(c draw.Canvas, scales []int) vg.Rectangle {
 	debug.V("Drawing descrete scales", scales)
-	showAlpha := containsInt(scales, AlphaScale)
-	showColor := containsInt(scales, ColorScale)
-	showFill := containsInt(scales, FillScale)
-	showShape := containsInt(scales, ShapeScale)
 	showSize := containsInt(scales, SizeScale)
-	showStroke := containsInt(scales, StrokeScale)
-	scale := plot.Scales[scales[0]] // all have same range (otherwise they would not have been combined), so take the first
-	ticker := plot.tickerFor(scales)
-	ticks := ticker.Ticks(scale.Limit.Min, scale.Limit.Max)
+	ticks := plot.guideTicks(scales, plot.tickerFor(scales))
 
-	boxSize, pad := plot.Style.Legend.Discrete.Size, vg.Length(3)
-	r := vg.Rectangle{
-		Min: vg.Point{c.Min.X, c.Max.Y - boxSize},
-		Max: vg.Point{c.Min.X + boxSize, c.Max.Y},
-	}
+	boxSize, pad := plot.Style.Legend.Discrete.Size, plot.Style.Legend.Discrete.Pad
+	top := vg.Point{X: c.Min.X, Y: c.Max.Y}
+	bbox := vg.Rectangle{Min: top, Max: top}
 
 	labelSty := plot.Style.Legend.Label
 	labelSty.XAlign = draw.XLeft
 
-	var pal []color.Color
-	if showColor || showFill {
-		cm := plot.colorMapFor(scales)
-		pal = cm.Palette(len(ticks)).Colors()
-	}
-
-	shape := draw.GlyphDrawer(draw.CircleGlyph{})
-	basecolor := plot.Style.GeomDefault.Color
-	size := boxSize / 5
-
-	for i, tick := range ticks {
+	keys := ticks[:0] // filtered in place
+	for _, tick := range ticks {
 		if tick.Label == "" {
 			debug.VV("skiping tick at", tick.Value)
 			continue
 		}
-		debug.VV("tick", tick.Label, "@", tick.Value)
-		// The background box.
-		c.SetColor(color.Gray{0xee})
-		c.Fill(r.Path())
-
-		center := vg.Point{X: (r.Min.X + r.Max.X) / 2, Y: (r.Min.Y + r.Max.Y) / 2}
-
-		col := basecolor
-		// The actual indicators.
-		if pal != nil {
-			col = pal[i]
+		keys = append(keys, tick)
+	}
+	if plot.Style.Legend.Discrete.Reverse || plot.reverseGuide(scales) {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
 		}
-		if showAlpha {
-			r, g, b, a := col.RGBA()
-			alpha := plot.Scales[AlphaScale].Map(tick.Value)
-			col = color.NRGBA64{uint16(r), uint16(g), uint16(b), uint16(float64(a) * alpha)}
+	}
+	if showSize {
+		// Graduated symbols: The boxes must accommodate the largest glyph.
+		for _, key := range keys {
+			boxSize = maxLength(boxSize, 2*plot.MapSize(key.Value)+pad)
 		}
+	}
+	widths := make([]vg.Length, len(keys))
+	for i, key := range keys {
+		widths[i] = boxSize + pad + textWidth(labelSty, key.Label)
+	}
+	offsets := plot.layoutKeys(widths, boxSize, c.Max.X-c.Min.X)
 
-		if showSize {
-			size = plot.MapSize(tick.Value)
-		}
-		if showShape {
-			shape = plotutil.Shape(i)
+	layers := plot.keyLayers(scales)
+	for i, tick := range keys {
+		debug.VV("tick", tick.Label, "@", tick.Value)
+		x, y := top.X+offsets[i].X, top.Y+offsets[i].Y
+		r := vg.Rectangle{
+			Min: vg.Point{X: x, Y: y - boxSize},
+			Max: vg.Point{X: x + boxSize, Y: y},
 		}
+		bbox = unionRect(bbox, r)
 
-		if showStroke {
-			lsty := draw.LineStyle{
-				Color:  col,
-				Width:  1,
-				Dashes: plotutil.Dashes(i),
-			}
-			c.StrokeLine2(lsty, r.Min.X, r.Min.Y, r.Max.X, r.Max.Y)
-		}
+		// The background box.
+		c.SetColor(plot.Style.Legend.Discrete.Background)
+		c.Fill(r.Path())
+
+		// The actual indicators.
+		plot.drawKey(c, r, plot.key(scales, tick.Value), layers)
 
-		// Do not draw the shape if not needed.
-		if showShape || showFill || showSize || showColor || (showAlpha && !showStroke) {
-			gsty := draw.GlyphStyle{
-				Color:  col,
-				Radius: size,
-				Shape:  shape,
-			}
-			c.DrawGlyph(gsty, center)
-		}
 		// The label.
-		c.FillText(labelSty, vg.Point{r.Max.X + pad, (r.Min.Y + r.Max.Y) / 2}, tick.Label)
+		pt := vg.Point{X: r.Max.X + pad, Y: (r.Min.Y + r.Max.Y) / 2}
+		c.FillText(labelSty, pt, tick.Label)
+		bbox = unionRect(bbox, textRect(labelSty, pt, tick.Label))
 
 		// The box border
 		c.SetColor(color.Black)
 		c.SetLineDash(nil, 0)
 		c.SetLineWidth(vg.Length(0.3))
 		c.Stroke(r.Path())
-
-		r.Min.Y -= boxSize + pad
-		r.Max.Y -= boxSize + pad
 	}
 
-	return r.Min.Y + boxSize - 2*pad
+	return bbox
 }
 
 func containsInt(s []int, v int) bool {
@@ -1001,52 +1456,203 @@ func (p *Plot) drawDiscreteColorGuide(c draw.Canvas, fill bool) vg.Length {
 	return r.Min.Y + size - 2*pad
 }
 
-func (p *Plot) drawContinuousColorGuide(c draw.Canvas, scale *Scale, colMap palette.ColorMap) vg.Length {
-	width := p.Style.Legend.Continuous.Size
-	height := p.Style.Legend.Continuous.Length
-	scale2Canvas := func(x float64) vg.Length {
-		t := scale.Map(x)
-		return c.Max.Y - height + height*vg.Length(t)
-	}
-	rect := vg.Rectangle{
-		Min: vg.Point{c.Min.X, scale2Canvas(scale.Limit.Min)},
-		Max: vg.Point{c.Min.X + width, scale2Canvas(scale.Limit.Max)},
+// barMark is a labeled position along a color bar, given as the fraction
+// f of the bar's length.
+type barMark struct {
+	f     float64
+	label string
+}
+
+// drawColorBar draws the guide for continuous Color, Fill and Alpha
+// scales as a bar. Continuous scales are drawn as a smooth gradient with
+// data values evenly spaced along the bar and labeled at the ticks of the
+// scale's Ticker. Binned scales are drawn as a stepped bar of equally
+// sized bins whose inner edges are labeled.
+// The bar is vertical unless p.Style.Legend.Continuous.Horizontal is set
+// or the legend is placed above or below the panels.
+func (p *Plot) drawColorBar(c draw.Canvas, scales []int) vg.Rectangle {
+	cont := p.Style.Legend.Continuous
+	scale := p.Scales[scales[0]]
+	horizontal := cont.Horizontal || p.horizontalLegend()
+
+	// The segments of the bar: segment i spans the fractions cuts[i]
+	// to cuts[i+1] of the bar and is colored like values[i].
+	var cuts, values []float64
+	var marks []barMark
+	if scale.Binned {
+		edges := scale.binEdges()
+		n := len(edges) - 1
+		labels := make(map[float64]string)
+		for _, tick := range p.guideTicks(scales, scale.ticker()) {
+			labels[tick.Value] = tick.Label
+		}
+		for k := 0; k <= n; k++ {
+			f := float64(k) / float64(n)
+			cuts = append(cuts, f)
+			if k == n {
+				break
+			}
+			values = append(values, (edges[k]+edges[k+1])/2)
+			if k > 0 {
+				label, ok := labels[edges[k]]
+				if !ok || label == "" {
+					label = p.guideValueLabel(scales, edges[k])
+				}
+				marks = append(marks, barMark{f, label})
+			}
+		}
+	} else {
+		U := Interval{0, 1}
+		for i := 0; i <= 100; i++ {
+			cuts = append(cuts, float64(i)/101)
+			x := scale.Trans.Inverse(U, scale.Range, float64(i)/100)
+			values = append(values, math.Max(scale.Range.Min, math.Min(scale.Range.Max, x)))
+		}
+		cuts = append(cuts, 1)
+		for _, tick := range p.guideTicks(scales, scale.ticker()) {
+			f := scale.Map(tick.Value)
+			if tick.IsMinor() || tick.Label == "" || f < 0 || f > 1 {
+				continue
+			}
+			marks = append(marks, barMark{f, tick.Label})
+		}
 	}
-	step := height / 101
-	r := rect
-	for i := 0; i <= 100; i++ {
-		col, err := colMap.At(float64(i) / 100)
-		if err != nil {
-			panic(fmt.Sprintf("%d %s", i, err))
+
+	// Labels are drawn after (right of or below) the bar or before it.
+	before := cont.LabelPosition == "left" || cont.LabelPosition == "top"
+	labelSty := p.Style.Legend.Label
+	gap := labelSty.Width(" ")
+	outside := (1 - vg.Length(cont.Tick.Align)) * cont.Tick.Length
+	var labelWidth, labelHeight vg.Length
+	for _, m := range marks {
+		labelWidth = maxLength(labelWidth, textWidth(labelSty, m.label))
+		labelHeight = maxLength(labelHeight, textHeight(labelSty, m.label))
+	}
+
+	top := vg.Point{X: c.Min.X, Y: c.Max.Y}
+	var rect vg.Rectangle
+	if horizontal {
+		x0, y1 := top.X+labelWidth/2, top.Y
+		if before {
+			y1 -= labelHeight + gap + outside
 		}
-		c.SetColor(col)
+		rect.Min = vg.Point{X: x0, Y: y1 - cont.Size}
+		rect.Max = vg.Point{X: x0 + cont.Length, Y: y1}
+	} else {
+		x0 := top.X
+		if before {
+			x0 += labelWidth + gap + outside
+		}
+		rect.Min = vg.Point{X: x0, Y: top.Y - cont.Length}
+		rect.Max = vg.Point{X: x0 + cont.Size, Y: top.Y}
+	}
+	// along converts the fraction f of the bar's length to a canvas
+	// coordinate; segment returns the part of the bar from f0 to f1.
+	reverse := p.reverseGuide(scales)
+	along := func(f float64) vg.Length {
+		if reverse {
+			f = 1 - f
+		}
+		if horizontal {
+			return rect.Min.X + vg.Length(f)*cont.Length
+		}
+		return rect.Min.Y + vg.Length(f)*cont.Length
+	}
+	segment := func(f0, f1 float64) vg.Rectangle {
+		r := rect
+		if horizontal {
+			r.Min.X, r.Max.X = along(f0), along(f1)
+		} else {
+			r.Min.Y, r.Max.Y = along(f0), along(f1)
+		}
+		return r
+	}
+
+	c.SetLineDash(nil, 0)
+	c.SetLineWidth(vg.Length(0.3))
+	for i, v := range values {
+		r := segment(cuts[i], cuts[i+1])
+		c.SetColor(p.barColor(scales, v))
 		c.Fill(r.Path())
-		r.Min.Y += step
+		if scale.Binned {
+			c.SetColor(color.Black)
+			c.Stroke(r.Path())
+		}
 	}
 	c.SetColor(color.Black)
-	c.SetLineWidth(vg.Length(0.3))
 	c.Stroke(rect.Path())
-	ticks := plot.DefaultTicks{}.Ticks(scale.Limit.Min, scale.Limit.Max)
-	for _, tick := range ticks {
-		if tick.IsMinor() {
-			continue
+
+	// The edges of the bar facing the labels and facing away.
+	labelEdge, otherEdge, out := rect.Max.X, rect.Min.X, vg.Length(1)
+	switch {
+	case horizontal && before:
+		labelEdge, otherEdge = rect.Max.Y, rect.Min.Y
+	case horizontal:
+		labelEdge, otherEdge, out = rect.Min.Y, rect.Max.Y, -1
+	case before:
+		labelEdge, otherEdge, out = rect.Min.X, rect.Max.X, -1
+	}
+	tick := func(edge, out vg.Length, at vg.Length) {
+		align := vg.Length(cont.Tick.Align)
+		a, b := edge-out*align*cont.Tick.Length, edge+out*(1-align)*cont.Tick.Length
+		if horizontal {
+			c.StrokeLine2(cont.Tick.LineStyle, at, a, at, b)
+		} else {
+			c.StrokeLine2(cont.Tick.LineStyle, a, at, b, at)
 		}
-		sty := p.Style.Legend.Continuous.Tick.LineStyle
-		length := p.Style.Legend.Continuous.Tick.Length
-		align := vg.Length(p.Style.Legend.Continuous.Tick.Align)
-		y := scale2Canvas(tick.Value)
-		x := rect.Max.X
-		c.StrokeLine2(sty, x-align*length, y, x+(1-align)*length, y)
+	}
 
-		if p.Style.Legend.Continuous.Tick.Mirror {
-			x := rect.Min.X
-			c.StrokeLine2(sty, x+(align-1)*length, y, x+align*length, y)
+	bbox := rect
+	for _, m := range marks {
+		at := along(m.f)
+		if !scale.Binned {
+			tick(labelEdge, out, at)
+			if cont.Tick.Mirror {
+				tick(otherEdge, -out, at)
+			}
+		}
+		sty := labelSty
+		var pt vg.Point
+		if horizontal {
+			pt = vg.Point{X: at, Y: labelEdge + out*(outside+gap)}
+			sty.XAlign, sty.YAlign = draw.XCenter, draw.YBottom
+			if out < 0 {
+				sty.YAlign = draw.YTop
+			}
+		} else {
+			pt = vg.Point{X: labelEdge + out*(outside+gap), Y: at}
+			sty.XAlign = draw.XLeft
+			if out < 0 {
+				sty.XAlign = draw.XRight
+			}
 		}
-		tsty := p.Style.Legend.Label
-		tsty.XAlign = draw.XLeft
-		c.FillText(tsty,
-			vg.Point{x + (1-align)*length, y}, " "+tick.Label)
+		c.FillText(sty, pt, m.label)
+		bbox = unionRect(bbox, textRect(sty, pt, m.label))
 	}
 
-	return rect.Min.Y
+	return bbox
+}
+
+// barColor returns the color of data value x in a color bar for the
+// given scales: Color or Fill scales determine the color, Alpha scales the
+// opacity of the default geom color.
+func (p *Plot) barColor(scales []int, x float64) color.Color {
+	col := p.Style.GeomDefault.Color
+	switch {
+	case containsInt(scales, ColorScale):
+		col = p.MapColor(x, false)
+	case containsInt(scales, FillScale):
+		col = p.MapColor(x, true)
+	}
+	if containsInt(scales, AlphaScale) {
+		col = withAlpha(col, p.MapAlpha(x))
+	}
+	return col
+}
+
+// withAlpha returns col with its opacity multiplied by alpha.
+func withAlpha(col color.Color, alpha float64) color.Color {
+	n := color.NRGBA64Model.Convert(col).(color.NRGBA64)
+	n.A = uint16(float64(n.A) * alpha)
+	return n
 }
@@ -0,0 +1,379 @@
+package facet
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/recorder"
+)
+
+func hostTicks(n int) plot.Ticker {
+	ticks := make(plot.ConstantTicks, n)
+	for i := range ticks {
+		ticks[i] = plot.Tick{Value: float64(i + 1),
+			Label: fmt.Sprintf("host-%02d.example.com", i+1)}
+	}
+	return ticks
+}
+
+func TestThinXLabels(t *testing.T) {
+	for _, tc := range []struct {
+		rotation float64
+		stagger  bool
+		width    vg.Length
+		want     int // number of labels kept
+	}{
+		{0, false, 2000, 12},
+		{0, false, 400, 3},
+		{0, true, 400, 6},
+		{math.Pi / 2, false, 400, 12},
+		{math.Pi / 2, false, 100, 6},
+		{math.Pi / 4, false, 400, 12},
+		{math.Pi / 4, false, 100, 4},
+	} {
+		p := NewSimplePlot()
+		p.Style.XAxis.MajorTick.Label.Rotation = tc.rotation
+		p.Style.XAxis.MajorTick.Stagger = tc.stagger
+		s := p.XScales[0]
+		s.Limit, s.Range = Interval{0.5, 12.5}, Interval{0.5, 12.5}
+		s.Ticker = hostTicks(12)
+
+		ticks := s.axisTicks(tc.width, 0, 4, vg.Font{}, false)
+		p.thinXLabels(s, ticks, tc.width, p.xTickLabelStyle(false))
+		got := 0
+		for _, tick := range ticks {
+			if tick.Label != "" {
+				got++
+			}
+		}
+		if got != tc.want {
+			t.Errorf("rotation=%.2f stagger=%t width=%.0f: got %d labels, want %d",
+				tc.rotation, tc.stagger, tc.width, got, tc.want)
+		}
+	}
+}
+
+func TestXTickHeight(t *testing.T) {
+	p := NewSimplePlot()
+	xticks := [][]plot.Tick{hostTicks(3).Ticks(0, 4)}
+	flat := p.xTickHeight(xticks, p.xTickLabelStyle(false))
+	p.Style.XAxis.MajorTick.Stagger = true
+	staggered := p.xTickHeight(xticks, p.xTickLabelStyle(false))
+	p.Style.XAxis.MajorTick.Stagger = false
+	p.Style.XAxis.MajorTick.Label.Rotation = math.Pi / 2
+	rotated := p.xTickHeight(xticks, p.xTickLabelStyle(false))
+
+	if staggered <= flat {
+		t.Errorf("staggered height %.1f not above flat height %.1f", staggered, flat)
+	}
+	width := p.Style.XAxis.MajorTick.Label.Width("host-01.example.com")
+	if rotated < width {
+		t.Errorf("rotated height %.1f less than label width %.1f", rotated, width)
+	}
+}
+
+func TestXTickLabelStyle(t *testing.T) {
+	for _, tc := range []struct {
+		rotation float64
+		top      bool
+		xalign   draw.XAlignment
+		yalign   draw.YAlignment
+	}{
+		{0, false, draw.XCenter, draw.YTop},
+		{0, true, draw.XCenter, draw.YBottom},
+		{math.Pi / 4, false, draw.XRight, draw.YCenter},
+		{math.Pi / 4, true, draw.XLeft, draw.YCenter},
+		{-math.Pi / 2, false, draw.XLeft, draw.YCenter},
+		{-math.Pi / 2, true, draw.XRight, draw.YCenter},
+	} {
+		p := NewSimplePlot()
+		p.Style.XAxis.MajorTick.Label.Rotation = tc.rotation
+		sty := p.xTickLabelStyle(tc.top)
+		if sty.XAlign != tc.xalign || sty.YAlign != tc.yalign {
+			t.Errorf("rotation=%.2f top=%t: got align %.1f/%.1f, want %.1f/%.1f",
+				tc.rotation, tc.top, sty.XAlign, sty.YAlign, tc.xalign, tc.yalign)
+		}
+	}
+}
+
+// axisTestPlot returns a plot of rows x cols empty panels whose X and Y
+// scales span [0,10] with labeled ticks x1, x2 and y1, y2.
+func axisTestPlot(rows, cols int) *Plot {
+	p := NewPlot(rows, cols, false, false)
+	x, y := p.XScales[0], p.YScales[0]
+	x.Limit, x.Range = Interval{0, 10}, Interval{0, 10}
+	x.Ticker = plot.ConstantTicks{{Value: 3, Label: "x1"}, {Value: 7, Label: "x2"}}
+	y.Limit, y.Range = Interval{0, 10}, Interval{0, 10}
+	y.Ticker = plot.ConstantTicks{{Value: 3, Label: "y1"}, {Value: 7, Label: "y2"}}
+	return p
+}
+
+// drawAxisTestPlot draws p onto a recorder canvas and returns the recorder.
+func drawAxisTestPlot(t *testing.T, p *Plot) *recorder.Canvas {
+	rec := &recorder.Canvas{}
+	c := draw.Canvas{Canvas: rec, Rectangle: vg.Rectangle{Max: vg.Point{X: 400, Y: 300}}}
+	if err := p.Draw(c); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return rec
+}
+
+// drawnStrings returns the positions of all occurrences of s drawn on rec.
+func drawnStrings(rec *recorder.Canvas, s string) []vg.Point {
+	var pts []vg.Point
+	for _, a := range rec.Actions {
+		if fs, ok := a.(*recorder.FillString); ok && fs.String == s {
+			pts = append(pts, fs.Point)
+		}
+	}
+	return pts
+}
+
+// strokedLines returns the end points of the straight lines stroked on rec.
+func strokedLines(rec *recorder.Canvas) [][2]vg.Point {
+	var lines [][2]vg.Point
+	for _, a := range rec.Actions {
+		if s, ok := a.(*recorder.Stroke); ok && len(s.Path) == 2 {
+			lines = append(lines, [2]vg.Point{s.Path[0].Pos, s.Path[1].Pos})
+		}
+	}
+	return lines
+}
+
+func TestNarrowFacetGridTicks(t *testing.T) {
+	p := NewPlot(5, 6, false, false)
+	x, y := p.XScales[0], p.YScales[0]
+	x.Limit, x.Range = Interval{0, 100}, Interval{0, 100}
+	y.Limit, y.Range = Interval{0, 10}, Interval{0, 10}
+	rec := &recorder.Canvas{}
+	c := draw.Canvas{Canvas: rec, Rectangle: vg.Rectangle{Max: vg.Point{X: 200, Y: 150}}}
+	if err := p.Draw(c); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The X tick labels below the bottom row, sorted by position.
+	font := p.Style.XAxis.MajorTick.Label.Font
+	bottom := p.Panels[4][0].Canvas.Rectangle.Min.Y
+	var labels []*recorder.FillString
+	for _, a := range rec.Actions {
+		if fs, ok := a.(*recorder.FillString); ok && fs.Point.Y < bottom {
+			labels = append(labels, fs)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Point.X < labels[j].Point.X })
+
+	for i := 1; i < len(labels); i++ {
+		prev := labels[i-1]
+		if end := prev.Point.X + font.Width(prev.String); end > labels[i].Point.X {
+			t.Errorf("label %q at %.1f overlaps %q at %.1f",
+				prev.String, prev.Point.X, labels[i].String, labels[i].Point.X)
+		}
+	}
+	for col := 0; col < 6; col++ {
+		r := p.Panels[4][col].Canvas.Rectangle
+		n := 0
+		for _, fs := range labels {
+			if fs.Point.X >= r.Min.X && fs.Point.X < r.Max.X {
+				n++
+			}
+		}
+		if n == 0 {
+			t.Errorf("column %d: no tick labels", col)
+		}
+	}
+}
+
+func TestAxisLine(t *testing.T) {
+	p := axisTestPlot(1, 1)
+	drawAxisTestPlot(t, p)
+	panel := p.Panels[0][0]
+	r := panel.Canvas.Rectangle
+	for _, visible := range []bool{false, true} {
+		if visible {
+			p.Style.XAxis.Line = draw.LineStyle{Color: color.Black, Width: 1}
+			p.Style.YAxis.Line = draw.LineStyle{Color: color.Black, Width: 1}
+		}
+		rec := &recorder.Canvas{}
+		panel.Canvas.Canvas = rec
+		p.drawXAxis(panel, nil, p.xTickLabelStyle(false), 0, false)
+		p.drawYAxis(panel, nil, false)
+		lines := strokedLines(rec)
+		if !visible {
+			if len(lines) != 0 {
+				t.Errorf("invisible axis lines drawn: %v", lines)
+			}
+			continue
+		}
+		want := [][2]vg.Point{{r.Min, {X: r.Max.X, Y: r.Min.Y}}, {r.Min, {X: r.Min.X, Y: r.Max.Y}}}
+		if fmt.Sprint(lines) != fmt.Sprint(want) {
+			t.Errorf("axis lines %v, want %v", lines, want)
+		}
+	}
+}
+
+func TestTickDirection(t *testing.T) {
+	p := axisTestPlot(1, 1)
+	drawAxisTestPlot(t, p)
+	panel := p.Panels[0][0]
+	r := panel.Canvas.Rectangle
+	ticks := []plot.Tick{{Value: 5, Label: "5"}}
+	for _, tc := range []struct {
+		align   float64
+		out, in vg.Length // extent of the tick outside and inside the panel
+	}{
+		{TicksOutside, 4, 0},
+		{TicksCrossing, 2, 2},
+		{TicksInside, 0, 4},
+	} {
+		p.Style.XAxis.MajorTick.LineStyle = draw.LineStyle{Color: color.Black, Width: 1}
+		p.Style.XAxis.MajorTick.Length = 4
+		p.Style.XAxis.MajorTick.Align = draw.YAlignment(tc.align)
+		p.Style.YAxis.MajorTick.LineStyle = draw.LineStyle{Color: color.Black, Width: 1}
+		p.Style.YAxis.MajorTick.Length = 4
+		p.Style.YAxis.MajorTick.Align = draw.XAlignment(tc.align)
+		for _, side := range []string{"bottom", "top", "left", "right"} {
+			rec := &recorder.Canvas{}
+			panel.Canvas.Canvas = rec
+			var border, out vg.Length // the panel border and the outwards direction
+			var coord func(vg.Point) vg.Length
+			switch side {
+			case "bottom", "top":
+				top := side == "top"
+				p.drawXAxis(panel, ticks, p.xTickLabelStyle(top), 0, top)
+				border, out = r.Min.Y, -1
+				if top {
+					border, out = r.Max.Y, 1
+				}
+				coord = func(pt vg.Point) vg.Length { return pt.Y }
+			default:
+				right := side == "right"
+				p.drawYAxis(panel, ticks, right)
+				border, out = r.Min.X, -1
+				if right {
+					border, out = r.Max.X, 1
+				}
+				coord = func(pt vg.Point) vg.Length { return pt.X }
+			}
+			lines := strokedLines(rec)
+			if len(lines) != 1 {
+				t.Errorf("align=%.1f %s: got %d lines, want 1", tc.align, side, len(lines))
+				continue
+			}
+			a, b := out*(coord(lines[0][0])-border), out*(coord(lines[0][1])-border)
+			if minLength(a, b) != -tc.in || maxLength(a, b) != tc.out {
+				t.Errorf("align=%.1f %s: tick from %v to %v, want %v to %v",
+					tc.align, side, minLength(a, b), maxLength(a, b), -tc.in, tc.out)
+			}
+		}
+	}
+}
+
+func TestAxisPosition(t *testing.T) {
+	for _, tc := range []struct {
+		x, y string
+	}{
+		{"bottom", "left"},
+		{"top", "left"},
+		{"", "right"},
+		{"top", "right"},
+	} {
+		p := axisTestPlot(1, 1)
+		p.Style.XAxis.Position, p.Style.YAxis.Position = tc.x, tc.y
+		rec := drawAxisTestPlot(t, p)
+		r := p.Panels[0][0].Canvas.Rectangle
+		for _, pt := range drawnStrings(rec, "x1") {
+			if top := pt.Y > r.Max.Y; top != (tc.x == "top") || (!top && pt.Y > r.Min.Y) {
+				t.Errorf("%s/%s: X label at %v, panel %v", tc.x, tc.y, pt, r)
+			}
+		}
+		for _, pt := range drawnStrings(rec, "y1") {
+			if right := pt.X > r.Max.X; right != (tc.y == "right") || (!right && pt.X > r.Min.X) {
+				t.Errorf("%s/%s: Y label at %v, panel %v", tc.x, tc.y, pt, r)
+			}
+		}
+		// The panel takes the space not needed by the axis.
+		if (tc.x == "top") != (r.Min.Y == 0) {
+			t.Errorf("%s/%s: panel %v", tc.x, tc.y, r)
+		}
+		if (tc.y == "right") != (r.Min.X == 0) {
+			t.Errorf("%s/%s: panel %v", tc.x, tc.y, r)
+		}
+	}
+
+	p := axisTestPlot(1, 1)
+	p.Style.XAxis.Position = "middle"
+	if err := p.Draw(draw.Canvas{Canvas: &recorder.Canvas{}}); err == nil {
+		t.Errorf("Missing error for X axis position middle")
+	}
+	p = axisTestPlot(1, 1)
+	p.Style.YAxis.Position = "Right"
+	if err := p.Draw(draw.Canvas{Canvas: &recorder.Canvas{}}); err == nil {
+		t.Errorf("Missing error for Y axis position Right")
+	}
+}
+
+func TestAxisRepeat(t *testing.T) {
+	for _, repeat := range []bool{false, true} {
+		p := axisTestPlot(2, 2)
+		p.Style.XAxis.Repeat, p.Style.YAxis.Repeat = repeat, repeat
+		rec := drawAxisTestPlot(t, p)
+		want := 2
+		if repeat {
+			want = 4
+		}
+		xlabels, ylabels := drawnStrings(rec, "x1"), drawnStrings(rec, "y1")
+		if len(xlabels) != want || len(ylabels) != want {
+			t.Errorf("repeat=%t: got %d X and %d Y labels, want %d",
+				repeat, len(xlabels), len(ylabels), want)
+		}
+
+		// Labels are drawn between and not onto the panels.
+		for _, panels := range p.Panels {
+			for _, panel := range panels {
+				r := panel.Canvas.Rectangle
+				for _, pt := range xlabels {
+					if pt.Y > r.Min.Y && pt.Y < r.Max.Y {
+						t.Errorf("repeat=%t: X label at %v in panel %v", repeat, pt, r)
+					}
+				}
+				for _, pt := range ylabels {
+					if pt.X > r.Min.X && pt.X < r.Max.X {
+						t.Errorf("repeat=%t: Y label at %v in panel %v", repeat, pt, r)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMinorGridLines(t *testing.T) {
+	xticks := []plot.Tick{{Value: 2, Label: "2"}, {Value: 3}}
+	for _, tc := range []struct {
+		major color.Color
+		want  int
+	}{
+		{color.White, 2},
+		{nil, 1},
+	} {
+		p := axisTestPlot(1, 1)
+		p.Style.Grid.Major.Color = tc.major
+		p.Style.Grid.Minor = draw.LineStyle{Color: color.Black, Width: 0.5}
+		rec := &recorder.Canvas{}
+		c := draw.Canvas{Canvas: rec, Rectangle: vg.Rectangle{Max: vg.Point{X: 100, Y: 100}}}
+		p.setupPanel(p.Panels[0][0], 0, 0, c, false, 0, 100, 100, 100, xticks, nil)
+		lines := strokedLines(rec)
+		if len(lines) != tc.want {
+			t.Errorf("major color %v: got %d grid lines, want %d", tc.major, len(lines), tc.want)
+			continue
+		}
+		if minor := lines[len(lines)-1]; minor[0].X != 30 || minor[1].X != 30 {
+			t.Errorf("major color %v: minor grid line %v, want at x=30", tc.major, minor)
+		}
+	}
+}
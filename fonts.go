@@ -0,0 +1,336 @@
+package facet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// Fonts
+
+// FontVariant selects one of the faces of a FontFamily.
+type FontVariant int
+
+const (
+	Regular FontVariant = iota
+	Bold
+	Italic
+	BoldItalic
+)
+
+// String returns the name of v, e.g. "bold".
+func (v FontVariant) String() string {
+	switch v {
+	case Regular:
+		return "regular"
+	case Bold:
+		return "bold"
+	case Italic:
+		return "italic"
+	case BoldItalic:
+		return "bolditalic"
+	}
+	return fmt.Sprintf("FontVariant(%d)", int(v))
+}
+
+// parseFontVariant returns the variant with the given (case insensitive)
+// name as returned by FontVariant.String.
+func parseFontVariant(name string) (FontVariant, error) {
+	for _, v := range []FontVariant{Regular, Bold, Italic, BoldItalic} {
+		if strings.EqualFold(strings.TrimSpace(name), v.String()) {
+			return v, nil
+		}
+	}
+	return Regular, fmt.Errorf("facet: unknown font variant %q", name)
+}
+
+// A FontFamily names the fonts used for the variants of a family. The
+// names must be known to vg.MakeFont, i.e. be one of the standard fonts
+// like "Times-Italic" or have been registered with RegisterFont or
+// RegisterFontFile. Missing variants fall back to Bold (for BoldItalic)
+// and Regular.
+type FontFamily struct {
+	Regular, Bold, Italic, BoldItalic string
+}
+
+// Face returns the name of the font used for variant v.
+func (f FontFamily) Face(v FontVariant) string {
+	name := ""
+	switch v {
+	case Bold:
+		name = f.Bold
+	case Italic:
+		name = f.Italic
+	case BoldItalic:
+		name = f.BoldItalic
+		if name == "" {
+			name = f.Bold
+		}
+	}
+	if name == "" {
+		name = f.Regular
+	}
+	return name
+}
+
+// DefaultFontFamily is the font family used by DefaultFacetStyle.
+var DefaultFontFamily = "Helvetica"
+
+// FallbackFonts are the names of the fonts used for text containing
+// characters the font of its text style has no glyphs for, e.g. a CJK font
+// registered with RegisterFontFile. The first font covering all characters
+// of the text is used. Only text styles using FallbackTextHandler or
+// MarkupTextHandler (like all text in DefaultFacetStyle) fall back.
+var FallbackFonts []string
+
+// fontFamilies is the registry of named font families.
+var fontFamilies = map[string]FontFamily{
+	"helvetica": helvetica,
+	"times":     {"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+	"courier":   {"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+}
+
+// RegisterFont parses the TrueType font data and makes it available
+// under the given name to vg.MakeFont, MakeFont and FontFamily. OpenType
+// fonts with PostScript (CFF) outlines are not supported.
+func RegisterFont(name string, data []byte) error {
+	font, err := truetype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("facet: cannot parse font %q: %s", name, err)
+	}
+	vg.AddFont(name, font)
+	fallbackCache.Lock()
+	fallbackCache.fonts = make(map[fallbackKey]vg.Font)
+	fallbackCache.Unlock()
+	return nil
+}
+
+// RegisterFontFile registers the TrueType font file path under the given
+// name, see RegisterFont.
+func RegisterFontFile(name, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("facet: cannot read font %q: %s", name, err)
+	}
+	return RegisterFont(name, data)
+}
+
+// RegisterFontFamily makes f available under the given name to MakeFont
+// and Style.SetFontFamily. Names are case insensitive; an existing family
+// of the same name is replaced.
+func RegisterFontFamily(name string, f FontFamily) {
+	fontFamilies[strings.ToLower(name)] = f
+}
+
+// FontFamilyByName returns the registered font family name, e.g.
+// "Helvetica", "Times" or "Courier".
+func FontFamilyByName(name string) (FontFamily, error) {
+	f, ok := fontFamilies[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return FontFamily{}, fmt.Errorf("facet: unknown font family %q", name)
+	}
+	return f, nil
+}
+
+// FontFamilyNames returns the sorted names of all registered font families.
+func FontFamilyNames() []string {
+	names := make([]string, 0, len(fontFamilies))
+	for name := range fontFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MakeFont returns the font of the given size for variant of family.
+// The family may also be the name of a single font which is then used for
+// all variants. An error is returned if the font cannot be loaded.
+func MakeFont(family string, variant FontVariant, size vg.Length) (vg.Font, error) {
+	name := family
+	if f, err := FontFamilyByName(family); err == nil {
+		name = f.Face(variant)
+	}
+	font, err := vg.MakeFont(name, size)
+	if err != nil {
+		return vg.Font{}, fmt.Errorf("facet: cannot load font %q: %s", name, err)
+	}
+	return font, nil
+}
+
+// helvetica is the built-in font family used if DefaultFontFamily cannot
+// be loaded. Its fonts are compiled into package vg.
+var helvetica = FontFamily{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"}
+
+// defaultFont is like MakeFont for the DefaultFontFamily but falls back to
+// the built-in helvetica if DefaultFontFamily cannot be loaded. As these
+// fonts are compiled into vg defaultFont panics only if vg is broken.
+func defaultFont(variant FontVariant, size vg.Length) vg.Font {
+	font, err := MakeFont(DefaultFontFamily, variant, size)
+	if err == nil {
+		return font
+	}
+	font, err = vg.MakeFont(helvetica.Face(variant), size)
+	if err != nil {
+		panic(err)
+	}
+	return font
+}
+
+// fontFamilyName returns the name of the first (by name) registered font
+// family which contains the font called name.
+func fontFamilyName(name string) (string, bool) {
+	for _, fname := range FontFamilyNames() {
+		f := fontFamilies[fname]
+		if f.Regular == name || f.Bold == name || f.Italic == name || f.BoldItalic == name {
+			return fname, true
+		}
+	}
+	return "", false
+}
+
+// fontFamilyOf returns the first (by name) registered font family which
+// contains the font called name.
+func fontFamilyOf(name string) (FontFamily, bool) {
+	fname, ok := fontFamilyName(name)
+	return fontFamilies[fname], ok
+}
+
+// fontVariant determines the variant of the font called name by looking it
+// up in the registered font families. Unknown fonts are Regular.
+func fontVariant(name string) FontVariant {
+	f, ok := fontFamilyOf(name)
+	if !ok {
+		return Regular
+	}
+	for _, v := range []FontVariant{BoldItalic, Italic, Bold} {
+		if f.Face(v) == name && f.Regular != name {
+			return v
+		}
+	}
+	return Regular
+}
+
+// SetFontFamily changes the fonts of all text elements of s to family,
+// keeping their size and variant. If a font of family cannot be loaded
+// s is left unchanged and an error is returned.
+func (s *Style) SetFontFamily(family string) error {
+	styles := textStyles(reflect.ValueOf(s).Elem())
+	fonts := make([]vg.Font, len(styles))
+	for i, sty := range styles {
+		font, err := MakeFont(family, fontVariant(sty.Font.Name()), sty.Font.Size)
+		if err != nil {
+			return err
+		}
+		fonts[i] = font
+	}
+	for i, sty := range styles {
+		sty.Font = fonts[i]
+	}
+	return nil
+}
+
+// textStyles returns pointers to all text styles in v, a (part of a) Style.
+func textStyles(v reflect.Value) []*draw.TextStyle {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if sty, ok := v.Addr().Interface().(*draw.TextStyle); ok {
+		return []*draw.TextStyle{sty}
+	}
+	var styles []*draw.TextStyle
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).PkgPath != "" {
+			continue
+		}
+		styles = append(styles, textStyles(v.Field(i))...)
+	}
+	return styles
+}
+
+// covers reports whether font has glyphs for all printable characters
+// of txt.
+func covers(font vg.Font, txt string) bool {
+	ttf := font.Font()
+	if ttf == nil {
+		return false
+	}
+	for _, r := range txt {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		if ttf.Index(r) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// coveringFont returns font if it covers txt and else the first of the
+// FallbackFonts (in the same size) which covers txt. If no font covers
+// txt or font has no glyph data to check font is returned.
+func coveringFont(font vg.Font, txt string) vg.Font {
+	if font.Font() == nil || covers(font, txt) {
+		return font
+	}
+	for _, name := range FallbackFonts {
+		fallback, ok := fallbackFont(name, font.Size)
+		if ok && covers(fallback, txt) {
+			return fallback
+		}
+	}
+	return font
+}
+
+// fallbackKey identifies a fallback font of a certain size.
+type fallbackKey struct {
+	name string
+	size vg.Length
+}
+
+// fallbackCache caches the fonts made by fallbackFont, fonts which cannot
+// be loaded as the zero vg.Font. It is cleared by RegisterFont.
+var fallbackCache = struct {
+	sync.Mutex
+	fonts map[fallbackKey]vg.Font
+}{fonts: make(map[fallbackKey]vg.Font)}
+
+// fallbackFont returns the font called name in the given size and
+// whether it can be loaded. The results are cached.
+func fallbackFont(name string, size vg.Length) (vg.Font, bool) {
+	key := fallbackKey{name, size}
+	fallbackCache.Lock()
+	defer fallbackCache.Unlock()
+	font, ok := fallbackCache.fonts[key]
+	if !ok {
+		font, _ = vg.MakeFont(name, size)
+		fallbackCache.fonts[key] = font
+	}
+	return font, font.Font() != nil
+}
+
+// FallbackTextHandler draws text like draw.PlainTextHandler but uses one
+// of the FallbackFonts if the font of the text style lacks glyphs for
+// some characters of the text, e.g. for CJK labels.
+type FallbackTextHandler struct{}
+
+var _ draw.TextHandler = FallbackTextHandler{}
+
+// Box implements draw.TextHandler.
+func (FallbackTextHandler) Box(txt string, font vg.Font) (width, height, depth vg.Length) {
+	return draw.PlainTextHandler{}.Box(txt, coveringFont(font, txt))
+}
+
+// Draw implements draw.TextHandler.
+func (FallbackTextHandler) Draw(c *draw.Canvas, txt string, sty draw.TextStyle, pt vg.Point) {
+	sty.Font = coveringFont(sty.Font, txt)
+	draw.PlainTextHandler{}.Draw(c, txt, sty, pt)
+}
@@ -0,0 +1,204 @@
+package facet
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+	"gonum.org/v1/plot/vg"
+)
+
+func TestFontFamilyFace(t *testing.T) {
+	full := FontFamily{"R", "B", "I", "BI"}
+	partial := FontFamily{Regular: "R", Bold: "B"}
+	for i, tc := range []struct {
+		family  FontFamily
+		variant FontVariant
+		want    string
+	}{
+		{full, Regular, "R"},
+		{full, Bold, "B"},
+		{full, Italic, "I"},
+		{full, BoldItalic, "BI"},
+		{partial, Italic, "R"},
+		{partial, BoldItalic, "B"},
+	} {
+		if got := tc.family.Face(tc.variant); got != tc.want {
+			t.Errorf("%d. Face(%d)=%q, want %q", i, tc.variant, got, tc.want)
+		}
+	}
+}
+
+func TestMakeFont(t *testing.T) {
+	for i, tc := range []struct {
+		family  string
+		variant FontVariant
+		want    string
+	}{
+		{"Helvetica", Regular, "Helvetica"},
+		{"times", Italic, "Times-Italic"},
+		{"Courier", BoldItalic, "Courier-BoldOblique"},
+		{"Times-Bold", Italic, "Times-Bold"},
+		{"NoSuchFont", Bold, ""},
+	} {
+		font, err := MakeFont(tc.family, tc.variant, 10)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("%d. MakeFont(%q): missing error", i, tc.family)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d. MakeFont(%q): unexpected error %s", i, tc.family, err)
+			continue
+		}
+		if font.Name() != tc.want || font.Size != 10 {
+			t.Errorf("%d. MakeFont(%q)=%s %v, want %s 10", i, tc.family,
+				font.Name(), font.Size, tc.want)
+		}
+	}
+}
+
+func TestDefaultFont(t *testing.T) {
+	defer func(family string) { DefaultFontFamily = family }(DefaultFontFamily)
+	defer RegisterFontFamily("Helvetica", helvetica)
+
+	for i, tc := range []struct {
+		family string
+		broken bool // helvetica family replaced by unloadable fonts
+		want   string
+	}{
+		{"Times", false, "Times-Italic"},
+		{"NoSuchFamily", false, "Helvetica-Oblique"},
+		{"Helvetica", true, "Helvetica-Oblique"},
+	} {
+		DefaultFontFamily = tc.family
+		RegisterFontFamily("Helvetica", helvetica)
+		if tc.broken {
+			RegisterFontFamily("Helvetica", FontFamily{Regular: "NoSuchFont"})
+		}
+		font := defaultFont(Italic, 10)
+		if font.Name() != tc.want || font.Size != 10 {
+			t.Errorf("%d. defaultFont=%s %v, want %s 10", i, font.Name(), font.Size, tc.want)
+		}
+	}
+}
+
+func TestRegisterFont(t *testing.T) {
+	if err := RegisterFont("Broken", []byte("no font")); err == nil {
+		t.Errorf("missing error for broken font data")
+	}
+	if err := RegisterFontFile("Missing", "testdata/no-such-font.ttf"); err == nil {
+		t.Errorf("missing error for missing font file")
+	}
+
+	if err := RegisterFont("Go-Regular", goregular.TTF); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	RegisterFontFamily("Go", FontFamily{Regular: "Go-Regular"})
+	font, err := MakeFont("go", Bold, 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if font.Name() != "Go-Regular" {
+		t.Errorf("Got font %q", font.Name())
+	}
+}
+
+func TestCoveringFont(t *testing.T) {
+	if err := RegisterFont("Go-Regular", goregular.TTF); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer func(fallbacks []string) { FallbackFonts = fallbacks }(FallbackFonts)
+
+	helvetica, _ := MakeFont("Helvetica", Regular, 10)
+	for i, tc := range []struct {
+		txt       string
+		fallbacks []string
+		want      string
+	}{
+		{"host-17", nil, "Helvetica"},
+		{"(10, ∞]\n< 5µs", nil, "Helvetica"},
+		{"a�b", nil, "Helvetica"},
+		{"a�b", []string{"Courier", "Go-Regular"}, "Go-Regular"},
+		{"主机-1", []string{"Go-Regular"}, "Helvetica"},
+	} {
+		FallbackFonts = tc.fallbacks
+		got := coveringFont(helvetica, tc.txt)
+		if got.Name() != tc.want || got.Size != 10 {
+			t.Errorf("%d. coveringFont(%q)=%s %v, want %s", i, tc.txt,
+				got.Name(), got.Size, tc.want)
+		}
+	}
+}
+
+func TestFallbackFontCache(t *testing.T) {
+	if err := RegisterFont("Go-Regular", goregular.TTF); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer func(fallbacks []string) { FallbackFonts = fallbacks }(FallbackFonts)
+	FallbackFonts = []string{"NoSuchFont", "Go-Regular"}
+
+	helvetica, _ := MakeFont("Helvetica", Regular, 10)
+	if got := coveringFont(helvetica, "a\uFFFDb"); got.Name() != "Go-Regular" {
+		t.Fatalf("Got %s", got.Name())
+	}
+	for _, tc := range []struct {
+		name string
+		ok   bool
+	}{{"NoSuchFont", false}, {"Go-Regular", true}} {
+		font, cached := fallbackCache.fonts[fallbackKey{tc.name, 10}]
+		if !cached || (font.Font() != nil) != tc.ok {
+			t.Errorf("%s: cached=%t loaded=%t", tc.name, cached, font.Font() != nil)
+		}
+	}
+
+	// Registering a font invalidates the cache.
+	if err := RegisterFont("Go-Regular", goregular.TTF); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if n := len(fallbackCache.fonts); n != 0 {
+		t.Errorf("%d fonts cached after RegisterFont", n)
+	}
+
+	// Fonts without glyph data are not checked.
+	if got := coveringFont(vg.Font{Size: 10}, "a\uFFFDb"); got.Name() != "" {
+		t.Errorf("font without glyph data replaced by %s", got.Name())
+	}
+}
+
+func TestSetFontFamily(t *testing.T) {
+	s := DefaultFacetStyle(12)
+	s.Legend.Label.Font, _ = MakeFont("Helvetica", Italic, 8)
+	if err := s.SetFontFamily("NoSuchFamily"); err == nil {
+		t.Fatalf("Missing error")
+	}
+	if got := s.Title.Font.Name(); got != "Helvetica-Bold" {
+		t.Errorf("Failed SetFontFamily changed title font to %s", got)
+	}
+
+	if err := s.SetFontFamily("Times"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, tc := range []struct {
+		font vg.Font
+		want string
+		size vg.Length
+	}{
+		{s.Title.Font, "Times-Bold", 14},
+		{s.XAxis.MajorTick.Label.Font, "Times-Bold", 10},
+		{s.HStrip.Font, "Times-Bold", 12},
+		{s.Legend.Label.Font, "Times-Italic", 8},
+	} {
+		if tc.font.Name() != tc.want || tc.font.Size != tc.size {
+			t.Errorf("Got %s %v, want %s %v", tc.font.Name(), tc.font.Size,
+				tc.want, tc.size)
+		}
+	}
+
+	for i, sty := range textStyles(reflect.ValueOf(&s).Elem()) {
+		if _, ok := sty.Handler.(FallbackTextHandler); !ok {
+			t.Errorf("%d. text style uses handler %T", i, sty.Handler)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package facet
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Label formats
+
+// The following functions return label formats suitable for Scale.Format
+// and Guide.Format. They are applied to the labeled ticks of any Ticker.
+
+// SIFormat formats values with an SI prefix followed by unit, e.g. 1200 as
+// "1.2k" and 0.0034 as "3.4m".
+func SIFormat(unit string) func(x float64) string {
+	return func(x float64) string {
+		return siLabel(x) + unit
+	}
+}
+
+// iecPrefixes are the binary (IEC) prefixes for 1024^0 to 1024^8.
+var iecPrefixes = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei", "Zi", "Yi"}
+
+// IECFormat formats values with a binary (IEC) prefix followed by unit,
+// e.g. IECFormat("B") formats 1536 as "1.5KiB" and 1<<30 as "1GiB".
+func IECFormat(unit string) func(x float64) string {
+	return func(x float64) string {
+		k := 0
+		for math.Abs(x) >= 1024 && k < len(iecPrefixes)-1 {
+			x /= 1024
+			k++
+		}
+		return strconv.FormatFloat(x, 'g', 4, 64) + iecPrefixes[k] + unit
+	}
+}
+
+// PercentFormat formats fractions as percentages with the given number of
+// decimals, e.g. 0.25 as "25%". Negative decimals use as many as needed.
+func PercentFormat(decimals int) func(x float64) string {
+	return func(x float64) string {
+		return strconv.FormatFloat(100*x, 'f', decimals, 64) + "%"
+	}
+}
+
+// DurationFormat formats values measured in unit like a time.Duration,
+// e.g. DurationFormat(time.Second) formats 0.0015 as "1.5ms" and 90 as
+// "1m30s". Values are rounded to full nanoseconds.
+func DurationFormat(unit time.Duration) func(x float64) string {
+	return func(x float64) string {
+		return time.Duration(math.Round(x * float64(unit))).String()
+	}
+}
+
+// ThousandsFormat formats values with the given number of decimals and
+// groups the digits of the integer part by sep, e.g. ThousandsFormat(",", 0)
+// formats 1234567 as "1,234,567". Negative decimals use as many as needed.
+func ThousandsFormat(sep string, decimals int) func(x float64) string {
+	return func(x float64) string {
+		return groupThousands(strconv.FormatFloat(x, 'f', decimals, 64), sep)
+	}
+}
+
+// ScientificFormat formats values in scientific notation with the given
+// number of significant digits and a superscript exponent, e.g. 1200 as
+// "1.2×10³" and 1000 as "10³".
+func ScientificFormat(digits int) func(x float64) string {
+	return func(x float64) string {
+		if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+			return strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		s := strconv.FormatFloat(x, 'e', digits-1, 64)
+		i := strings.IndexByte(s, 'e')
+		mantissa, exp := s[:i], s[i+1:]
+		if strings.Contains(mantissa, ".") {
+			mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+		}
+		e, _ := strconv.Atoi(exp)
+		power := "10" + superscript(strconv.Itoa(e))
+		switch mantissa {
+		case "1":
+			return power
+		case "-1":
+			return "-" + power
+		}
+		return mantissa + "×" + power
+	}
+}
+
+// CurrencyFormat formats values as amounts of money with the given symbol
+// and number of decimals and with thousands separated by ",", e.g.
+// CurrencyFormat("$", 2) formats -1234.5 as "-$1,234.50".
+func CurrencyFormat(symbol string, decimals int) func(x float64) string {
+	return func(x float64) string {
+		sign := ""
+		if x < 0 {
+			sign, x = "-", -x
+		}
+		return sign + symbol + groupThousands(strconv.FormatFloat(x, 'f', decimals, 64), ",")
+	}
+}
+
+// groupThousands inserts sep between groups of three digits of the integer
+// part of the formatted number s.
+func groupThousands(s, sep string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	frac := ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + frac
+}
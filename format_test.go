@@ -0,0 +1,70 @@
+package facet
+
+import (
+	"testing"
+	"time"
+
+	"gonum.org/v1/plot/vg"
+)
+
+var formatTests = []struct {
+	name   string
+	format func(x float64) string
+	x      float64
+	want   string
+}{
+	{"SI", SIFormat(""), 1200, "1.2k"},
+	{"SI", SIFormat("s"), 0.0034, "3.4ms"},
+	{"SI", SIFormat("B"), 0, "0B"},
+	{"SI", SIFormat(""), 999999, "1M"},
+	{"SI", SIFormat(""), -999.99, "-1k"},
+	{"SI", SIFormat(""), 999.9, "999.9"},
+	{"IEC", IECFormat("B"), 1536, "1.5KiB"},
+	{"IEC", IECFormat("B"), 1 << 30, "1GiB"},
+	{"IEC", IECFormat("B"), 100, "100B"},
+	{"Percent", PercentFormat(0), 0.25, "25%"},
+	{"Percent", PercentFormat(1), 0.125, "12.5%"},
+	{"Duration", DurationFormat(time.Second), 0.0015, "1.5ms"},
+	{"Duration", DurationFormat(time.Second), 0.3, "300ms"},
+	{"Duration", DurationFormat(time.Millisecond), 0.25, "250µs"},
+	{"Duration", DurationFormat(time.Second), 90, "1m30s"},
+	{"Thousands", ThousandsFormat(",", 0), 1234567, "1,234,567"},
+	{"Thousands", ThousandsFormat("'", 2), -1234.5, "-1'234.50"},
+	{"Thousands", ThousandsFormat(",", -1), 999, "999"},
+	{"Scientific", ScientificFormat(3), 1200, "1.2×10³"},
+	{"Scientific", ScientificFormat(3), 1000, "10³"},
+	{"Scientific", ScientificFormat(2), 0.00025, "2.5×10⁻⁴"},
+	{"Scientific", ScientificFormat(2), 0, "0"},
+	{"Currency", CurrencyFormat("$", 2), -1234.5, "-$1,234.50"},
+	{"Currency", CurrencyFormat("€", 0), 100, "€100"},
+}
+
+func TestFormat(t *testing.T) {
+	for i, tc := range formatTests {
+		if got := tc.format(tc.x); got != tc.want {
+			t.Errorf("%d. %s(%g) = %q, want %q", i, tc.name, tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestScaleFormat(t *testing.T) {
+	s := NewScale()
+	s.Limit, s.Range = Interval{0, 2048}, Interval{0, 2048}
+	s.Ticker = DefaultTicks(3)
+	s.Format = IECFormat("B")
+	for _, tick := range s.axisTicks(0, 0, 3, vg.Font{}, false) {
+		if tick.Label != "" && tick.Label != IECFormat("B")(tick.Value) {
+			t.Errorf("tick %g labeled %q", tick.Value, tick.Label)
+		}
+	}
+
+	p := NewSimplePlot()
+	p.Scales[SizeScale].Format = PercentFormat(0)
+	if got := p.guideFormat(SizeScale)(0.5); got != "50%" {
+		t.Errorf("guide uses scale format: got %q", got)
+	}
+	p.Scales[SizeScale].Guide.Format = SIFormat("")
+	if got := p.guideFormat(SizeScale)(0.5); got != "500m" {
+		t.Errorf("guide format overrides scale format: got %q", got)
+	}
+}
@@ -0,0 +1,255 @@
+package geom
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/vdobler/facet"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// Annotations
+
+// Annotations are geoms which are not tied to data rows: They are placed
+// at fixed data coordinates and do not map any aesthetic. They can be added
+// to the Geoms of a single panel or to facet.Plot.Annotations to show up
+// in all panels. An annotation extends the X and Y scales to cover itself
+// only if Extend is set. Coordinates may be +/-Inf to span the whole
+// range of the scale, e.g. for shaded ranges.
+
+// TextAnnotation draws Text at (X,Y).
+type TextAnnotation struct {
+	X, Y float64
+	Text string
+
+	Extend  bool
+	Default draw.TextStyle
+}
+
+// Draw implements facet.Geom.Draw.
+func (t TextAnnotation) Draw(panel *facet.Panel) {
+	pt := mapAnnotation(panel, t.X, t.Y)
+	if !panel.Canvas.Contains(pt) {
+		return
+	}
+	sty := t.Default
+	if sty.Color == nil {
+		sty.Color = panel.Plot.Style.GeomDefault.Color
+	}
+	if sty.Font == (vg.Font{}) {
+		sty.Font = panel.Plot.Style.XAxis.Title.Font
+	}
+	panel.Canvas.FillText(sty, pt, t.Text)
+}
+
+// DataRange implements facet.Geom.DataRange.
+func (t TextAnnotation) DataRange() facet.DataRanges {
+	return annotationRange(t.Extend, t.X, t.Y)
+}
+
+// RectAnnotation draws a shaded rectangle between (X,Y) and (U,V).
+// Use e.g. Y=-Inf and V=+Inf to shade the whole height of the panel.
+type RectAnnotation struct {
+	X, Y, U, V float64
+
+	Extend  bool
+	Default BoxStyle
+}
+
+// Draw implements facet.Geom.Draw.
+func (r RectAnnotation) Draw(panel *facet.Panel) {
+	fill, border := r.Default.Fill, r.Default.Border
+	if fill == nil && border.Color == nil {
+		fill = color.NRGBA{0x80, 0x80, 0x80, 0x40}
+	}
+
+	x, u := clampInterval(panel.Scales[facet.XScale].View(), r.X, r.U)
+	y, v := clampInterval(panel.Scales[facet.YScale].View(), r.Y, r.V)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return // completely outside
+	}
+	rect := CanonicRectangle(vg.Rectangle{Min: panel.MapXY(x, y), Max: panel.MapXY(u, v)})
+
+	canvas := panel.Canvas
+	if fill != nil {
+		canvas.SetColor(fill)
+		canvas.Fill(rect.Path())
+	}
+	if border.Color != nil && border.Width > 0 {
+		canvas.SetColor(border.Color)
+		canvas.SetLineWidth(border.Width)
+		canvas.SetLineDash(border.Dashes, border.DashOffs)
+		canvas.Stroke(rect.Path())
+	}
+}
+
+// DataRange implements facet.Geom.DataRange.
+func (r RectAnnotation) DataRange() facet.DataRanges {
+	return annotationRange(r.Extend, r.X, r.Y, r.U, r.V)
+}
+
+// ArrowAnnotation draws an arrow from (X,Y) to (U,V).
+type ArrowAnnotation struct {
+	X, Y, U, V float64
+
+	// HeadLength is the length of the two lines forming the arrow head.
+	HeadLength vg.Length
+
+	Extend  bool
+	Default draw.LineStyle
+}
+
+// Draw implements facet.Geom.Draw.
+func (a ArrowAnnotation) Draw(panel *facet.Panel) {
+	sty := annotationLineStyle(panel, a.Default)
+	from, to := mapAnnotation(panel, a.X, a.Y), mapAnnotation(panel, a.U, a.V)
+	canvas := panel.Canvas
+	canvas.StrokeLines(sty, canvas.ClipLinesXY([]vg.Point{from, to})...)
+
+	if !canvas.Contains(to) || from == to {
+		return // no head if tip is outside
+	}
+	head := a.HeadLength
+	if head == 0 {
+		head = 8
+	}
+	angle := math.Atan2(float64(from.Y-to.Y), float64(from.X-to.X))
+	for _, phi := range []float64{angle - math.Pi/6, angle + math.Pi/6} {
+		end := vg.Point{
+			X: to.X + head*vg.Length(math.Cos(phi)),
+			Y: to.Y + head*vg.Length(math.Sin(phi)),
+		}
+		canvas.StrokeLines(sty, canvas.ClipLinesXY([]vg.Point{to, end})...)
+	}
+}
+
+// DataRange implements facet.Geom.DataRange.
+func (a ArrowAnnotation) DataRange() facet.DataRanges {
+	return annotationRange(a.Extend, a.X, a.Y, a.U, a.V)
+}
+
+// BracketAnnotation draws a bracket from (X,Y) to (U,V), e.g. to mark
+// a significant difference between two groups. The tips of length Tip
+// point to the right of the direction from (X,Y) to (U,V), i.e. downwards
+// for a bracket drawn from left to right. The optional Text is drawn at
+// the center of the bracket on the opposite side of the tips.
+type BracketAnnotation struct {
+	X, Y, U, V float64
+	Text       string
+
+	// Tip is the length of the bracket's tips.
+	Tip vg.Length
+
+	Extend    bool
+	Default   draw.LineStyle
+	TextStyle draw.TextStyle
+}
+
+// Draw implements facet.Geom.Draw.
+func (b BracketAnnotation) Draw(panel *facet.Panel) {
+	sty := annotationLineStyle(panel, b.Default)
+	from, to := mapAnnotation(panel, b.X, b.Y), mapAnnotation(panel, b.U, b.V)
+	if from == to {
+		return
+	}
+	tip := b.Tip
+	if tip == 0 {
+		tip = 5
+	}
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := vg.Length(math.Hypot(float64(dx), float64(dy)))
+	normal := vg.Point{X: dy / length * tip, Y: -dx / length * tip}
+
+	canvas := panel.Canvas
+	canvas.StrokeLines(sty, canvas.ClipLinesXY([]vg.Point{
+		from.Add(normal), from, to, to.Add(normal),
+	})...)
+
+	if b.Text == "" {
+		return
+	}
+	center := vg.Point{X: (from.X + to.X) / 2, Y: (from.Y + to.Y) / 2}
+	if !panel.Canvas.Contains(center) {
+		return
+	}
+	tsty := b.TextStyle
+	if tsty.Color == nil {
+		tsty.Color = sty.Color
+	}
+	if tsty.Font == (vg.Font{}) {
+		tsty.Font = panel.Plot.Style.XAxis.Title.Font
+	}
+	tsty.XAlign, tsty.YAlign = draw.XCenter, draw.YCenter
+	offset := tsty.Font.Size
+	center = center.Add(vg.Point{X: -normal.X / tip * offset, Y: -normal.Y / tip * offset})
+	canvas.FillText(tsty, center, b.Text)
+}
+
+// DataRange implements facet.Geom.DataRange.
+func (b BracketAnnotation) DataRange() facet.DataRanges {
+	return annotationRange(b.Extend, b.X, b.Y, b.U, b.V)
+}
+
+// annotationRange returns the data ranges of an annotation with the given
+// x,y coordinate pairs: Only finite coordinates of annotations which
+// extend the scales are reported.
+func annotationRange(extend bool, xy ...float64) facet.DataRanges {
+	dr := facet.NewDataRanges()
+	if !extend {
+		return dr
+	}
+	for i := 0; i+1 < len(xy); i += 2 {
+		if x := xy[i]; !math.IsInf(x, 0) {
+			dr[facet.XScale].Update(x)
+		}
+		if y := xy[i+1]; !math.IsInf(y, 0) {
+			dr[facet.YScale].Update(y)
+		}
+	}
+	return dr
+}
+
+// clampInterval clamps [a,b] to r. NaNs are returned if [a,b] and r
+// do not overlap.
+func clampInterval(r facet.Interval, a, b float64) (float64, float64) {
+	if a > b {
+		a, b = b, a
+	}
+	if b < r.Min || a > r.Max {
+		return math.NaN(), math.NaN()
+	}
+	return math.Max(a, r.Min), math.Min(b, r.Max)
+}
+
+// mapAnnotation maps the annotation coordinates (x,y) to the panel: An
+// infinite coordinate is replaced by the corresponding end of the scale's
+// view, i.e. maps to the border of the panel.
+func mapAnnotation(panel *facet.Panel, x, y float64) vg.Point {
+	x = clampInfinite(panel.Scales[facet.XScale].View(), x)
+	y = clampInfinite(panel.Scales[facet.YScale].View(), y)
+	return panel.MapXY(x, y)
+}
+
+// clampInfinite returns the end of r for x = -Inf or +Inf and x otherwise.
+func clampInfinite(r facet.Interval, x float64) float64 {
+	switch {
+	case math.IsInf(x, -1):
+		return r.Min
+	case math.IsInf(x, +1):
+		return r.Max
+	}
+	return x
+}
+
+// annotationLineStyle fills unset fields of sty with the geom defaults.
+func annotationLineStyle(panel *facet.Panel, sty draw.LineStyle) draw.LineStyle {
+	if sty.Color == nil {
+		sty.Color = panel.Plot.Style.GeomDefault.Color
+	}
+	if sty.Width == 0 {
+		sty.Width = panel.Plot.Style.GeomDefault.LineWidth
+	}
+	return sty
+}
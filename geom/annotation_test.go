@@ -0,0 +1,97 @@
+package geom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vdobler/facet"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/recorder"
+)
+
+func TestAnnotationDataRange(t *testing.T) {
+	r := RectAnnotation{X: 2, Y: math.Inf(-1), U: 5, V: math.Inf(+1)}
+	if dr := r.DataRange(); !math.IsNaN(dr[facet.XScale].Min) {
+		t.Errorf("non-extending annotation has X range %v", dr[facet.XScale])
+	}
+
+	r.Extend = true
+	dr := r.DataRange()
+	if got := dr[facet.XScale]; got.Min != 2 || got.Max != 5 {
+		t.Errorf("X range = %v, want [2,5]", got)
+	}
+	if got := dr[facet.YScale]; !math.IsNaN(got.Min) {
+		t.Errorf("infinite Y range = %v, want unset", got)
+	}
+	for s := facet.AlphaScale; s <= facet.StrokeScale; s++ {
+		if !math.IsNaN(dr[s].Min) {
+			t.Errorf("annotation trains scale %d", s)
+		}
+	}
+}
+
+func TestClampInterval(t *testing.T) {
+	r := facet.Interval{Min: 0, Max: 10}
+	for i, tc := range []struct{ a, b, wantA, wantB float64 }{
+		{2, 5, 2, 5},
+		{5, 2, 2, 5},
+		{math.Inf(-1), math.Inf(1), 0, 10},
+		{-5, 5, 0, 5},
+	} {
+		a, b := clampInterval(r, tc.a, tc.b)
+		if a != tc.wantA || b != tc.wantB {
+			t.Errorf("%d. clampInterval(%g,%g) = %g,%g, want %g,%g",
+				i, tc.a, tc.b, a, b, tc.wantA, tc.wantB)
+		}
+	}
+	if a, _ := clampInterval(r, 11, 12); !math.IsNaN(a) {
+		t.Errorf("disjoint interval not reported")
+	}
+}
+
+func TestInfiniteAnnotations(t *testing.T) {
+	p := facet.NewSimplePlot()
+	rec := &recorder.Canvas{}
+	panel := p.Panels[0][0]
+	panel.Canvas = draw.Canvas{
+		Canvas:    rec,
+		Rectangle: vg.Rectangle{Max: vg.Point{X: 100, Y: 100}},
+	}
+	for _, s := range []int{facet.XScale, facet.YScale} {
+		panel.Scales[s] = facet.NewScale()
+		panel.Scales[s].Range = facet.Interval{Min: 0, Max: 10}
+		panel.Scales[s].Trans = facet.LinearTrans
+	}
+
+	TextAnnotation{X: math.Inf(+1), Y: 5, Text: "right"}.Draw(panel)
+	ArrowAnnotation{X: math.Inf(-1), Y: 5, U: 5, V: 5}.Draw(panel)
+	BracketAnnotation{X: 2, Y: math.Inf(+1), U: 8, V: math.Inf(+1)}.Draw(panel)
+
+	var text *recorder.FillString
+	var lines [][]vg.Point
+	for _, a := range rec.Actions {
+		switch a := a.(type) {
+		case *recorder.FillString:
+			text = a
+		case *recorder.Stroke:
+			var pts []vg.Point
+			for _, c := range a.Path {
+				pts = append(pts, c.Pos)
+			}
+			lines = append(lines, pts)
+		}
+	}
+	if text == nil || text.Point.X != 100 {
+		t.Errorf("text at +Inf drawn at %v, want at the right border", text)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 3 of the arrow and 1 of the bracket", len(lines))
+	}
+	if arrow := lines[0]; arrow[0] != (vg.Point{X: 0, Y: 50}) || arrow[1] != (vg.Point{X: 50, Y: 50}) {
+		t.Errorf("arrow from -Inf drawn from %v to %v", arrow[0], arrow[1])
+	}
+	if bracket := lines[3]; bracket[1] != (vg.Point{X: 20, Y: 100}) || bracket[2] != (vg.Point{X: 80, Y: 100}) {
+		t.Errorf("bracket at +Inf drawn along %v", bracket)
+	}
+}
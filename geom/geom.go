@@ -16,12 +16,12 @@ package geom
 import (
 	"fmt"
 	"image/color"
+	"math"
 	"sort"
 
 	"github.com/vdobler/facet"
 	"github.com/vdobler/facet/data"
 	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
 )
@@ -59,13 +59,13 @@ func (p Point) Draw(panel *facet.Panel) {
 
 	for i := 0; i < p.XY.Len(); i++ {
 		x, y := p.XY.XY(i)
-		if !panel.InRangeXY(x, y) {
+		center, ok := panel.PlaceXY(x, y)
+		if !ok || !panel.Canvas.Contains(center) {
 			// TODO: how to report infromation without producing
 			// a flood of identical messages?
 			// fmt.Fprintf(panel.Plot.Messages, "removed point")
 			continue
 		}
-		center := panel.MapXY(x, y)
 
 		col, ok := determineColor(baseColor, panel, i, p.Color, p.Alpha)
 		if !ok {
@@ -73,7 +73,10 @@ func (p Point) Draw(panel *facet.Panel) {
 		}
 
 		if p.Shape != nil {
-			shape = plotutil.Shape(p.Shape(i))
+			shape = panel.MapShape(p.Shape(i))
+			if shape == nil {
+				continue
+			}
 		}
 
 		if p.Size != nil {
@@ -92,6 +95,40 @@ func (p Point) Draw(panel *facet.Panel) {
 	}
 }
 
+// DrawKey implements facet.KeyDrawer by drawing the point's glyph.
+func (p Point) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	baseColor := p.Default.Color
+	if baseColor == nil {
+		baseColor = plot.Style.GeomDefault.Color
+	}
+
+	size := p.Default.Radius
+	if size == 0 {
+		size = plot.Style.GeomDefault.Size
+	}
+	if key.Shows(facet.SizeScale) {
+		size = key.Size
+	}
+
+	shape := p.Default.Shape
+	if shape == nil {
+		shape = draw.GlyphDrawer(draw.CircleGlyph{})
+	}
+	if key.Shows(facet.ShapeScale) {
+		shape = key.Shape
+	}
+	if shape == nil || size == 0 {
+		return
+	}
+
+	sty := draw.GlyphStyle{
+		Color:  key.StrokeColor(baseColor),
+		Radius: size,
+		Shape:  shape,
+	}
+	c.DrawGlyph(sty, keyCenter(r))
+}
+
 func (p Point) DataRange() facet.DataRanges {
 	dr := facet.NewDataRanges()
 	xmin, xmax, ymin, ymax := plotter.XYRange(p.XY)
@@ -105,6 +142,17 @@ func (p Point) DataRange() facet.DataRanges {
 	return dr
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (p Point) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	for i := 0; i < p.XY.Len(); i++ {
+		x, y := p.XY.XY(i)
+		pr.x(x)
+		pr.y(y)
+	}
+	return pr.result()
+}
+
 // ----------------------------------------------------------------------------
 // Rectangle
 
@@ -140,58 +188,110 @@ func clipRect(rect vg.Rectangle, canvas draw.Canvas) vg.Rectangle {
 		rect.Max.X = limit.Max.X
 	}
 	if rect.Max.Y > limit.Max.Y {
-		rect.Min.Y = limit.Min.Y
+		rect.Max.Y = limit.Max.Y
 	}
 	return rect
 }
 
 // Draw implements facet.Geom.Draw.
 func (r Rectangle) Draw(panel *facet.Panel) {
-	fill := r.Default.Fill
-	border := r.Default.Border
-	if fill == nil && border.Color == nil {
-		border.Color = color.RGBA{0, 0, 0x10, 0xff}
-		border.Width = 2 // TODO ??
-	}
-	if fill == nil && border.Color == nil {
-		border.Color = color.RGBA{0, 0, 0x10, 0xff}
-		border.Width = 2 // TODO ??
-	}
+	fill, border := r.style()
 
 	for i := 0; i < r.XYUV.Len(); i++ {
 		x, y, u, v := r.XYUV.XYUV(i)
-		if !panel.InRangeXY(x, y) && !panel.InRangeXY(u, v) {
-			continue // both corners outside of scale range
+		min, okmin := panel.PlaceXY(x, y)
+		max, okmax := panel.PlaceXY(u, v)
+		if !okmin || !okmax {
+			continue // a corner is censored
+		}
+		full := CanonicRectangle(vg.Rectangle{Min: min, Max: max})
+		rect := clipRect(full, panel.Canvas)
+		if rect.Min.X >= rect.Max.X || rect.Min.Y >= rect.Max.Y {
+			continue // completely clipped
 		}
-		min, max := panel.MapXY(x, y), panel.MapXY(u, v)
-		rect := vg.Rectangle{Min: min, Max: max}
-		rect = clipRect(rect, panel.Canvas)
 
-		if fillCol, ok := determineColor(fill, panel, i, r.Fill, r.Alpha); ok {
+		if fillCol, ok := determineFill(fill, panel, i, r.Fill, r.Alpha); ok {
 			panel.Canvas.SetColor(fillCol)
 			panel.Canvas.Fill(rect.Path())
 		}
 		if r.Size != nil {
 			border.Width = panel.MapSize(r.Size(i))
 		}
+		if r.Stroke != nil {
+			border.Dashes = panel.MapStroke(r.Stroke(i))
+		}
 		if border.Width <= 0 {
 			continue
 		}
 
 		if borderCol, ok := determineColor(border.Color, panel, i, r.Color, r.Alpha); ok {
 			w := 0.499 * border.Width
-			rect.Min.X += w
-			rect.Min.Y += w
-			rect.Max.X -= w
-			rect.Max.Y -= w
-			panel.Canvas.SetColor(borderCol)
-			panel.Canvas.SetLineWidth(border.Width)
-			panel.Canvas.SetLineDash(border.Dashes, border.DashOffs)
-			panel.Canvas.Stroke(rect.Path())
+			full.Min.X += w
+			full.Min.Y += w
+			full.Max.X -= w
+			full.Max.Y -= w
+			if full == clipRect(full, panel.Canvas) {
+				panel.Canvas.SetColor(borderCol)
+				panel.Canvas.SetLineWidth(border.Width)
+				panel.Canvas.SetLineDash(border.Dashes, border.DashOffs)
+				panel.Canvas.Stroke(full.Path())
+				continue
+			}
+			// Clipped borders are not drawn along the panel edge.
+			border.Color = borderCol
+			outline := []vg.Point{full.Min, {X: full.Max.X, Y: full.Min.Y},
+				full.Max, {X: full.Min.X, Y: full.Max.Y}, full.Min}
+			panel.Canvas.StrokeLines(border, panel.Canvas.ClipLinesXY(outline)...)
 		}
 	}
 }
 
+// style returns the default fill color and border style of r.
+func (r Rectangle) style() (color.Color, draw.LineStyle) {
+	fill := r.Default.Fill
+	border := r.Default.Border
+	if fill == nil && border.Color == nil {
+		border.Color = color.RGBA{0, 0, 0x10, 0xff}
+		border.Width = 2 // TODO ??
+	}
+	return fill, border
+}
+
+// DrawKey implements facet.KeyDrawer by drawing a filled rectangle.
+func (r Rectangle) DrawKey(plot *facet.Plot, c draw.Canvas, box vg.Rectangle, key facet.Key) {
+	box.Min.X, box.Min.Y = box.Min.X+1, box.Min.Y+1
+	box.Max.X, box.Max.Y = box.Max.X-1, box.Max.Y-1
+	r.drawKeyBox(c, box, key)
+}
+
+// drawKeyBox draws box with r's fill and border as determined by key.
+func (r Rectangle) drawKeyBox(c draw.Canvas, box vg.Rectangle, key facet.Key) {
+	fill, border := r.style()
+	if fill = key.FillColor(fill); fill != nil {
+		c.SetColor(fill)
+		c.Fill(box.Path())
+	}
+	if key.Shows(facet.SizeScale) {
+		border.Width = key.Size
+	}
+	if key.Shows(facet.StrokeScale) {
+		border.Dashes = key.Dashes
+	}
+	border.Color = key.StrokeColor(border.Color)
+	if border.Width <= 0 || border.Color == nil {
+		return
+	}
+	w := 0.499 * border.Width
+	box.Min.X += w
+	box.Min.Y += w
+	box.Max.X -= w
+	box.Max.Y -= w
+	c.SetColor(border.Color)
+	c.SetLineWidth(border.Width)
+	c.SetLineDash(border.Dashes, border.DashOffs)
+	c.Stroke(box.Path())
+}
+
 func (r Rectangle) DataRange() facet.DataRanges {
 	dr := facet.NewDataRanges()
 	xmin, xmax, ymin, ymax, umin, umax, vmin, vmax := data.XYUVRange(r.XYUV)
@@ -201,6 +301,17 @@ func (r Rectangle) DataRange() facet.DataRanges {
 	return dr
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (r Rectangle) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	for i := 0; i < r.XYUV.Len(); i++ {
+		x, y, u, v := r.XYUV.XYUV(i)
+		pr.x(x, u)
+		pr.y(y, v)
+	}
+	return pr.result()
+}
+
 // ----------------------------------------------------------------------------
 // Bar
 
@@ -228,11 +339,33 @@ func (b Bar) Draw(p *facet.Panel) {
 	rect.Draw(p)
 }
 
+// DrawKey implements facet.KeyDrawer by drawing a filled rectangle.
+func (b Bar) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	Rectangle{Default: b.Default}.DrawKey(plot, c, r, key)
+}
+
 func (b Bar) DataRange() facet.DataRanges {
 	rect := b.rects()
 	return rect.DataRange()
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger. The baseline
+// y=0 of the bars is not counted as a non-positive value.
+func (b Bar) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	rect := b.rects()
+	for i := 0; i < rect.XYUV.Len(); i++ {
+		x, y, u, v := rect.XYUV.XYUV(i)
+		pr.x(x, u)
+		for _, w := range []float64{y, v} {
+			if w != 0 {
+				pr.y(w)
+			}
+		}
+	}
+	return pr.result()
+}
+
 func (b Bar) rects() Rectangle {
 	if b.Position == "" {
 		b.Position = "stack"
@@ -478,18 +611,18 @@ func (p Path) Draw(panel *facet.Panel) {
 	for i := 0; i < p.XY.Len()-1; i++ {
 		x, y := p.XY.XY(i)
 		u, v := p.XY.XY(i + 1)
-		if !panel.InRangeXY(x, y) && !panel.InRangeXY(u, v) {
-			continue // both corners outside of scale range
+		left, okl := panel.PlaceXY(x, y)  // Clipping done below.
+		right, okr := panel.PlaceXY(u, v) // Clipping done below.
+		if !okl || !okr {
+			continue // an end point is censored
 		}
-		left := panel.MapXY(x, y)  // Clipping done below.
-		right := panel.MapXY(u, v) // Clipping done below.
 
 		col, ok := determineColor(baseColor, panel, i, p.Color, p.Alpha)
 		if !ok {
 			continue // TODO: report dropping of data to Plot/Panel
 		}
 		if p.Stroke != nil {
-			dashes = plotutil.Dashes(p.Stroke(i))
+			dashes = panel.MapStroke(p.Stroke(i))
 		}
 		if p.Size != nil {
 			width = panel.MapSize(p.Size(i))
@@ -506,6 +639,11 @@ func (p Path) Draw(panel *facet.Panel) {
 	}
 }
 
+// DrawKey implements facet.KeyDrawer by drawing a horizontal line.
+func (p Path) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, p.Default, false)
+}
+
 func (p Path) DataRange() facet.DataRanges {
 	dr := facet.NewDataRanges()
 	for i := 0; i < p.XY.Len(); i++ {
@@ -517,6 +655,11 @@ func (p Path) DataRange() facet.DataRanges {
 	return dr
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (p Path) PositiveDataRange() (facet.DataRanges, int, int) {
+	return Point{XY: p.XY}.PositiveDataRange()
+}
+
 // ----------------------------------------------------------------------------
 // Line
 
@@ -553,11 +696,21 @@ func (l Line) Draw(panel *facet.Panel) {
 	path.Draw(panel)
 }
 
+// DrawKey implements facet.KeyDrawer by drawing a horizontal line.
+func (l Line) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, l.Default, false)
+}
+
 func (l Line) DataRange() facet.DataRanges {
 	path := Path(l) // no need to sort
 	return path.DataRange()
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (l Line) PositiveDataRange() (facet.DataRanges, int, int) {
+	return Point{XY: l.XY}.PositiveDataRange()
+}
+
 // ----------------------------------------------------------------------------
 // Step
 
@@ -609,6 +762,11 @@ func (s Step) Draw(panel *facet.Panel) {
 	path.Draw(panel)
 }
 
+// DrawKey implements facet.KeyDrawer by drawing a horizontal line.
+func (s Step) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, s.Default, false)
+}
+
 func (s Step) DataRange() facet.DataRanges {
 	// all additional points lie inside the range spaned by the original data points.
 	path := Path{Alpha: s.Alpha, Color: s.Color, Size: s.Size,
@@ -616,6 +774,11 @@ func (s Step) DataRange() facet.DataRanges {
 	return path.DataRange()
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (s Step) PositiveDataRange() (facet.DataRanges, int, int) {
+	return Point{XY: s.XY}.PositiveDataRange()
+}
+
 // ----------------------------------------------------------------------------
 // Segment
 
@@ -647,18 +810,18 @@ func (s Segment) Draw(panel *facet.Panel) {
 	canvas := panel.Canvas
 	for i := 0; i < s.XYUV.Len(); i++ {
 		x, y, u, v := s.XYUV.XYUV(i)
-		if !panel.InRangeXY(x, y) && !panel.InRangeXY(u, v) {
-			continue // both corners outside of scale range
+		left, okl := panel.PlaceXY(x, y)  // Clipping done below.
+		right, okr := panel.PlaceXY(u, v) // Clipping done below.
+		if !okl || !okr {
+			continue // an end point is censored
 		}
-		left := panel.MapXY(x, y)  // Clipping done below.
-		right := panel.MapXY(u, v) // Clipping done below.
 
 		col, ok := determineColor(baseColor, panel, i, s.Color, s.Alpha)
 		if !ok {
 			continue // TODO: report dropping of data to Plot/Panel
 		}
 		if s.Stroke != nil {
-			dashes = plotutil.Dashes(s.Stroke(i))
+			dashes = panel.MapStroke(s.Stroke(i))
 		}
 		if s.Size != nil {
 			width = panel.MapSize(s.Size(i))
@@ -675,6 +838,11 @@ func (s Segment) Draw(panel *facet.Panel) {
 	}
 }
 
+// DrawKey implements facet.KeyDrawer by drawing a horizontal line.
+func (s Segment) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, s.Default, false)
+}
+
 func (s Segment) DataRange() facet.DataRanges {
 	dr := facet.NewDataRanges()
 	for i := 0; i < s.XYUV.Len(); i++ {
@@ -688,10 +856,16 @@ func (s Segment) DataRange() facet.DataRanges {
 	return dr
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (s Segment) PositiveDataRange() (facet.DataRanges, int, int) {
+	return Rectangle{XYUV: s.XYUV}.PositiveDataRange()
+}
+
 // ----------------------------------------------------------------------------
-// HLine
+// Reference lines: HLine, VLine and ABLine
 
 // HLine draws horizontal reference (or rule) lines at the given Y values.
+// The lines span the visible Range of the x-axis.
 type HLine struct {
 	Y plotter.Valuer
 
@@ -700,36 +874,64 @@ type HLine struct {
 	Size   Aesthetic
 	Stroke DiscreteAesthetic
 
-	Default draw.LineStyle
+	// Label, if non-nil, returns the label of line i drawn above the
+	// right end of the line.
+	Label func(i int) string
+
+	// Group, if non-nil, restricts line i to the panels showing Group(i).
+	// This allows different reference values per facet.
+	Group func(i int) facet.GroupID
+
+	Default      draw.LineStyle
+	DefaultLabel draw.TextStyle
 }
 
 func (h HLine) Draw(panel *facet.Panel) {
-	N := h.Y.Len()
-	xyuv := make(data.XYUVs, N)
-	xscale := panel.Scales[facet.XScale]
-	xmin, xmax := xscale.Limit.Min, xscale.Limit.Max
-	for i := 0; i < N; i++ {
-		y := h.Y.Value(i)
-		xyuv[i].X, xyuv[i].Y, xyuv[i].U, xyuv[i].V = xmin, y, xmax, y
+	xr := visibleRange(panel, facet.XScale)
+	lines := []refLine{}
+	for i := 0; i < h.Y.Len(); i++ {
+		if showsLine(panel, h.Group, i) {
+			y := h.Y.Value(i)
+			lines = append(lines, refLine{i, xr.Min, y, xr.Max, y})
+		}
 	}
-	segment := Segment{XYUV: xyuv, Default: h.Default}
-	CopyAesthetics(&segment, h, nil)
-	segment.Draw(panel)
+	label := h.DefaultLabel
+	label.XAlign, label.YAlign = draw.XRight, draw.YBottom
+	drawRefLines(panel, lines, h, h.Default, h.Label, label)
+}
+
+// DrawKey implements facet.KeyDrawer by drawing a horizontal line.
+func (h HLine) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, h.Default, false)
 }
 
 func (h HLine) DataRange() facet.DataRanges {
+	return h.PanelDataRange(nil)
+}
+
+// PanelDataRange implements facet.PanelDataRanger.
+func (h HLine) PanelDataRange(panel *facet.Panel) facet.DataRanges {
 	dr := facet.NewDataRanges()
 	for i := 0; i < h.Y.Len(); i++ {
-		dr[facet.YScale].Update(h.Y.Value(i))
+		if panel == nil || showsLine(panel, h.Group, i) {
+			dr[facet.YScale].Update(h.Y.Value(i))
+		}
 	}
 	UpdateAestheticsRanges(&dr, h.Y.Len(), h.Alpha, h.Color, nil, nil, h.Size, h.Stroke)
 	return dr
 }
 
-// ----------------------------------------------------------------------------
-// VLine
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (h HLine) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	for i := 0; i < h.Y.Len(); i++ {
+		pr.y(h.Y.Value(i))
+	}
+	return pr.result()
+}
 
 // VLine draws vertical reference (or rule) lines at the given X values.
+// The lines span the visible Range of the y-axis.
 type VLine struct {
 	X plotter.Valuer
 
@@ -738,32 +940,124 @@ type VLine struct {
 	Size   Aesthetic
 	Stroke DiscreteAesthetic
 
-	Default draw.LineStyle
+	// Label, if non-nil, returns the label of line i drawn left of the
+	// top end of the line.
+	Label func(i int) string
+
+	// Group, if non-nil, restricts line i to the panels showing Group(i).
+	Group func(i int) facet.GroupID
+
+	Default      draw.LineStyle
+	DefaultLabel draw.TextStyle
 }
 
 func (v VLine) Draw(panel *facet.Panel) {
-	N := v.X.Len()
-	xyuv := make(data.XYUVs, N)
-	yscale := panel.Scales[facet.YScale]
-	ymin, ymax := yscale.Limit.Min, yscale.Limit.Max
-	for i := 0; i < N; i++ {
-		x := v.X.Value(i)
-		xyuv[i].X, xyuv[i].Y, xyuv[i].U, xyuv[i].V = x, ymin, x, ymax
+	yr := visibleRange(panel, facet.YScale)
+	lines := []refLine{}
+	for i := 0; i < v.X.Len(); i++ {
+		if showsLine(panel, v.Group, i) {
+			x := v.X.Value(i)
+			lines = append(lines, refLine{i, x, yr.Min, x, yr.Max})
+		}
 	}
-	segment := Segment{XYUV: xyuv, Default: v.Default}
-	CopyAesthetics(&segment, v, nil)
-	segment.Draw(panel)
+	label := v.DefaultLabel
+	label.XAlign, label.YAlign = draw.XRight, draw.YTop
+	drawRefLines(panel, lines, v, v.Default, v.Label, label)
+}
+
+// DrawKey implements facet.KeyDrawer by drawing a vertical line.
+func (v VLine) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, v.Default, true)
 }
 
 func (v VLine) DataRange() facet.DataRanges {
+	return v.PanelDataRange(nil)
+}
+
+// PanelDataRange implements facet.PanelDataRanger.
+func (v VLine) PanelDataRange(panel *facet.Panel) facet.DataRanges {
 	dr := facet.NewDataRanges()
 	for i := 0; i < v.X.Len(); i++ {
-		dr[facet.XScale].Update(v.X.Value(i))
+		if panel == nil || showsLine(panel, v.Group, i) {
+			dr[facet.XScale].Update(v.X.Value(i))
+		}
 	}
 	UpdateAestheticsRanges(&dr, v.X.Len(), v.Alpha, v.Color, nil, nil, v.Size, v.Stroke)
 	return dr
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (v VLine) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	for i := 0; i < v.X.Len(); i++ {
+		pr.x(v.X.Value(i))
+	}
+	return pr.result()
+}
+
+// ABLine draws the straight lines y = Intercept + Slope*x clipped to the
+// visible Range of the x- and y-axis. ABLines do not extend the x- or
+// y-axis.
+type ABLine struct {
+	Intercept, Slope plotter.Valuer
+
+	Alpha  Aesthetic
+	Color  Aesthetic
+	Size   Aesthetic
+	Stroke DiscreteAesthetic
+
+	// Label, if non-nil, returns the label of line i drawn above the
+	// right end of the visible part of the line.
+	Label func(i int) string
+
+	// Group, if non-nil, restricts line i to the panels showing Group(i).
+	Group func(i int) facet.GroupID
+
+	Default      draw.LineStyle
+	DefaultLabel draw.TextStyle
+}
+
+func (a ABLine) Draw(panel *facet.Panel) {
+	xr := visibleRange(panel, facet.XScale)
+	yr := visibleRange(panel, facet.YScale)
+	lines := []refLine{}
+	for i := 0; i < a.Intercept.Len(); i++ {
+		if !showsLine(panel, a.Group, i) {
+			continue
+		}
+		icept, slope := a.Intercept.Value(i), a.Slope.Value(i)
+		xmin, xmax := xr.Min, xr.Max
+		if slope != 0 {
+			// Restrict x to where y is inside the y Range.
+			x1, x2 := (yr.Min-icept)/slope, (yr.Max-icept)/slope
+			if x1 > x2 {
+				x1, x2 = x2, x1
+			}
+			xmin, xmax = math.Max(xmin, x1), math.Min(xmax, x2)
+		} else if icept < yr.Min || icept > yr.Max {
+			continue
+		}
+		if xmin > xmax {
+			continue // line does not cross the panel
+		}
+		lines = append(lines, refLine{i, xmin, icept + slope*xmin, xmax, icept + slope*xmax})
+	}
+	label := a.DefaultLabel
+	label.XAlign, label.YAlign = draw.XRight, draw.YBottom
+	drawRefLines(panel, lines, a, a.Default, a.Label, label)
+}
+
+// DrawKey implements facet.KeyDrawer by drawing a horizontal line.
+func (a ABLine) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	drawLineKey(plot, c, r, key, a.Default, false)
+}
+
+func (a ABLine) DataRange() facet.DataRanges {
+	dr := facet.NewDataRanges()
+	UpdateAestheticsRanges(&dr, a.Intercept.Len(), a.Alpha, a.Color, nil, nil, a.Size, a.Stroke)
+	return dr
+}
+
 // ----------------------------------------------------------------------------
 // Boxplot
 
@@ -842,6 +1136,28 @@ func (b Boxplot) Draw(panel *facet.Panel) {
 	point.Draw(panel)
 }
 
+// DrawKey implements facet.KeyDrawer by drawing a small boxplot.
+func (b Boxplot) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	w, h := r.Max.X-r.Min.X, r.Max.Y-r.Min.Y
+	lower := vg.Rectangle{
+		Min: vg.Point{X: r.Min.X, Y: r.Min.Y + h/10},
+		Max: vg.Point{X: r.Max.X, Y: r.Min.Y + h/4},
+	}
+	upper := vg.Rectangle{
+		Min: vg.Point{X: r.Min.X, Y: r.Max.Y - h/4},
+		Max: vg.Point{X: r.Max.X, Y: r.Max.Y - h/10},
+	}
+	drawLineKey(plot, c, lower, key, b.Default.Border, true)
+	drawLineKey(plot, c, upper, key, b.Default.Border, true)
+
+	box := vg.Rectangle{
+		Min: vg.Point{X: r.Min.X + w/5, Y: r.Min.Y + h/4},
+		Max: vg.Point{X: r.Max.X - w/5, Y: r.Max.Y - h/4},
+	}
+	Rectangle{Default: b.Default}.drawKeyBox(c, box, key)
+	drawLineKey(plot, c, box, key, b.Default.Border, false)
+}
+
 func (b Boxplot) DataRange() facet.DataRanges {
 	dr := facet.NewDataRanges()
 	g := NewBarGroups(b.Position, b.GGap, b.BGap, true)
@@ -860,6 +1176,18 @@ func (b Boxplot) DataRange() facet.DataRanges {
 	return dr
 }
 
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (b Boxplot) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	for i := 0; i < b.Boxplot.Len(); i++ {
+		x, min, _, _, _, max, out := b.Boxplot.Boxplot(i)
+		pr.x(x)
+		pr.y(min, max)
+		pr.y(out...)
+	}
+	return pr.result()
+}
+
 // ----------------------------------------------------------------------------
 // Text
 
@@ -888,10 +1216,10 @@ func (t Text) Draw(panel *facet.Panel) {
 
 	for i := 0; i < t.XYText.Len(); i++ {
 		x, y, text := t.XYText.XYText(i)
-		if !panel.InRangeXY(x, y) {
+		center, ok := panel.PlaceXY(x, y)
+		if !ok || !panel.Canvas.Contains(center) {
 			continue // TODO: should notify Plot/Panel about dropped data point.
 		}
-		center := panel.MapXY(x, y)
 
 		col, ok := determineColor(baseColor, panel, i, t.Color, t.Alpha)
 		if !ok {
@@ -913,6 +1241,29 @@ func (t Text) Draw(panel *facet.Panel) {
 	}
 }
 
+// DrawKey implements facet.KeyDrawer by drawing the letter "a".
+func (t Text) DrawKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key) {
+	baseColor := t.Default.Color
+	if baseColor == nil {
+		baseColor = plot.Style.GeomDefault.Color
+	}
+
+	sty := t.Default
+	sty.Color = key.StrokeColor(baseColor)
+	sty.Font = plot.Style.XAxis.Title.Font
+	if t.Default.Font != (vg.Font{}) {
+		sty.Font = t.Default.Font
+	}
+	if key.Shows(facet.SizeScale) {
+		if key.Size == 0 {
+			return
+		}
+		sty.Font.Size = 2 * key.Size
+	}
+	sty.XAlign, sty.YAlign = draw.XCenter, draw.YCenter
+	c.FillText(sty, keyCenter(r), "a")
+}
+
 func (t Text) DataRange() facet.DataRanges {
 	dr := facet.NewDataRanges()
 	for i := 0; i < t.XYText.Len(); i++ {
@@ -923,3 +1274,14 @@ func (t Text) DataRange() facet.DataRanges {
 	UpdateAestheticsRanges(&dr, t.XYText.Len(), t.Alpha, t.Color, nil, nil, t.Size, nil)
 	return dr
 }
+
+// PositiveDataRange implements facet.PositiveDataRanger.
+func (t Text) PositiveDataRange() (facet.DataRanges, int, int) {
+	pr := newPositiveRange()
+	for i := 0; i < t.XYText.Len(); i++ {
+		x, y, _ := t.XYText.XYText(i)
+		pr.x(x)
+		pr.y(y)
+	}
+	return pr.result()
+}
@@ -0,0 +1,72 @@
+package geom
+
+import (
+	"testing"
+
+	"github.com/vdobler/facet"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/recorder"
+)
+
+var clipRectTests = []struct {
+	rect, want vg.Rectangle
+}{
+	{
+		vg.Rectangle{Min: vg.Point{X: 10, Y: 10}, Max: vg.Point{X: 20, Y: 20}},
+		vg.Rectangle{Min: vg.Point{X: 10, Y: 10}, Max: vg.Point{X: 20, Y: 20}},
+	},
+	{
+		vg.Rectangle{Min: vg.Point{X: -10, Y: -10}, Max: vg.Point{X: 120, Y: 120}},
+		vg.Rectangle{Min: vg.Point{X: 0, Y: 0}, Max: vg.Point{X: 100, Y: 100}},
+	},
+	{
+		vg.Rectangle{Min: vg.Point{X: 50, Y: 150}, Max: vg.Point{X: 60, Y: 20}},
+		vg.Rectangle{Min: vg.Point{X: 50, Y: 20}, Max: vg.Point{X: 60, Y: 100}},
+	},
+}
+
+func TestClipRect(t *testing.T) {
+	canvas := draw.Canvas{
+		Canvas:    &recorder.Canvas{},
+		Rectangle: vg.Rectangle{Max: vg.Point{X: 100, Y: 100}},
+	}
+	for i, tc := range clipRectTests {
+		if got := clipRect(tc.rect, canvas); got != tc.want {
+			t.Errorf("%d. clipRect(%v) = %v, want %v", i, tc.rect, got, tc.want)
+		}
+	}
+}
+
+func TestZoomKeepsCrossingLines(t *testing.T) {
+	p := facet.NewSimplePlot()
+	rec := &recorder.Canvas{}
+	panel := p.Panels[0][0]
+	panel.Canvas = draw.Canvas{
+		Canvas:    rec,
+		Rectangle: vg.Rectangle{Max: vg.Point{X: 100, Y: 100}},
+	}
+	for _, s := range []int{facet.XScale, facet.YScale} {
+		panel.Scales[s] = facet.NewScale()
+		panel.Scales[s].Range = facet.Interval{Min: 0, Max: 10}
+		panel.Scales[s].Zoom = facet.Interval{Min: 4, Max: 6}
+	}
+
+	// Both end points lie outside of the zoom window.
+	Path{XY: plotter.XYs{{X: 0, Y: 0}, {X: 10, Y: 10}}}.Draw(panel)
+	strokes := 0
+	for _, a := range rec.Actions {
+		if s, ok := a.(*recorder.Stroke); ok {
+			strokes++
+			for _, c := range s.Path {
+				if !panel.Canvas.Contains(c.Pos) {
+					t.Errorf("line not clipped: %v", c.Pos)
+				}
+			}
+		}
+	}
+	if strokes != 1 {
+		t.Errorf("got %d strokes, want 1", strokes)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/vdobler/facet"
+	"github.com/vdobler/facet/data"
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
 )
@@ -105,6 +106,15 @@ func CanonicRectangle(r vg.Rectangle) vg.Rectangle {
 	return r
 }
 
+// determineFill works like determineColor but maps fillF via the
+// panel's Fill scale.
+func determineFill(col color.Color, panel *facet.Panel, i int, fillF, alphaF Aesthetic) (color.Color, bool) {
+	if fillF != nil {
+		col = panel.MapFill(fillF(i))
+	}
+	return determineColor(col, panel, i, nil, alphaF)
+}
+
 func determineColor(col color.Color, panel *facet.Panel, i int, colorF, alphaF Aesthetic) (color.Color, bool) {
 	if colorF != nil {
 		col = panel.MapColor(colorF(i))
@@ -115,8 +125,8 @@ func determineColor(col color.Color, panel *facet.Panel, i int, colorF, alphaF A
 	}
 
 	if alphaF != nil {
-		alpha := panel.Scales[facet.AlphaScale].Map(alphaF(i))
-		if alpha < 0 || alpha > 1 || math.IsNaN(alpha) {
+		alpha := panel.MapAlpha(alphaF(i))
+		if math.IsNaN(alpha) {
 			return col, false
 		}
 		r, g, b, a := col.RGBA()
@@ -130,3 +140,138 @@ func determineColor(col color.Color, panel *facet.Panel, i int, colorF, alphaF A
 
 	return col, true
 }
+
+// keyCenter returns the center of the key box r.
+func keyCenter(r vg.Rectangle) vg.Point {
+	return vg.Point{X: (r.Min.X + r.Max.X) / 2, Y: (r.Min.Y + r.Max.Y) / 2}
+}
+
+// drawLineKey draws a horizontal (or vertical) line through the center of
+// the key box r in the line style def as modified by key.
+func drawLineKey(plot *facet.Plot, c draw.Canvas, r vg.Rectangle, key facet.Key, def draw.LineStyle, vertical bool) {
+	sty := def
+	if sty.Color == nil {
+		sty.Color = plot.Style.GeomDefault.Color
+	}
+	if sty.Width == 0 {
+		sty.Width = plot.Style.GeomDefault.LineWidth
+	}
+	sty.Color = key.StrokeColor(sty.Color)
+	if key.Shows(facet.SizeScale) {
+		sty.Width = key.Size
+	}
+	if key.Shows(facet.StrokeScale) {
+		sty.Dashes = key.Dashes
+	}
+	if sty.Width <= 0 {
+		return
+	}
+
+	center := keyCenter(r)
+	if vertical {
+		c.StrokeLine2(sty, center.X, r.Min.Y, center.X, r.Max.Y)
+	} else {
+		c.StrokeLine2(sty, r.Min.X, center.Y, r.Max.X, center.Y)
+	}
+}
+
+// refLine is the reference line i drawn from (x,y) to (u,v).
+type refLine struct {
+	i          int
+	x, y, u, v float64
+}
+
+// visibleRange returns the part of the Range of the X or Y scale of panel
+// which is shown, i.e. which lies inside the scale's View.
+func visibleRange(panel *facet.Panel, scale int) facet.Interval {
+	s := panel.Scales[scale]
+	v := s.View()
+	return facet.Interval{
+		Min: math.Max(v.Min, s.Range.Min),
+		Max: math.Min(v.Max, s.Range.Max),
+	}
+}
+
+// showsLine reports whether the reference line i is drawn in panel.
+func showsLine(panel *facet.Panel, group func(int) facet.GroupID, i int) bool {
+	return group == nil || panel.Shows(group(i))
+}
+
+// drawRefLines draws the reference lines with the aesthetics of the geom
+// src and labels the (u,v) end of the lines with label(i) using sty.
+func drawRefLines(panel *facet.Panel, lines []refLine, src interface{}, def draw.LineStyle, label func(int) string, sty draw.TextStyle) {
+	xyuv := make(data.XYUVs, len(lines))
+	for j, l := range lines {
+		xyuv[j].X, xyuv[j].Y, xyuv[j].U, xyuv[j].V = l.x, l.y, l.u, l.v
+	}
+	segment := Segment{XYUV: xyuv, Default: def}
+	CopyAesthetics(&segment, src, func(j int) int { return lines[j].i })
+	segment.Draw(panel)
+
+	if label == nil {
+		return
+	}
+	if sty.Color == nil {
+		sty.Color = panel.Plot.Style.GeomDefault.Color
+	}
+	if sty.Font == (vg.Font{}) {
+		sty.Font = panel.Plot.Style.Legend.Label.Font
+	}
+	pad := sty.Font.Size / 4
+	for _, l := range lines {
+		text := label(l.i)
+		pt := panel.MapXY(l.u, l.v)
+		if text == "" || !panel.Canvas.Contains(pt) {
+			continue
+		}
+		lsty := sty
+		if lsty.YAlign == draw.YBottom && pt.Y+pad+lsty.Height(text) > panel.Canvas.Max.Y {
+			lsty.YAlign = draw.YTop // would leave the panel
+		}
+		pt.X -= pad
+		pt.Y += pad
+		if lsty.YAlign == draw.YTop {
+			pt.Y -= 2 * pad
+		}
+		panel.Canvas.FillText(lsty, pt, text)
+	}
+}
+
+// positiveRange collects the positive x and y values of a geom and counts
+// the non-positive ones. It is used to implement facet.PositiveDataRanger.
+type positiveRange struct {
+	dr     facet.DataRanges
+	nx, ny int
+}
+
+func newPositiveRange() *positiveRange {
+	return &positiveRange{dr: facet.NewDataRanges()}
+}
+
+// x records the x values xs. NaNs are ignored.
+func (p *positiveRange) x(xs ...float64) {
+	p.nx += updatePositive(&p.dr[facet.XScale], xs)
+}
+
+// y records the y values ys. NaNs are ignored.
+func (p *positiveRange) y(ys ...float64) {
+	p.ny += updatePositive(&p.dr[facet.YScale], ys)
+}
+
+func (p *positiveRange) result() (facet.DataRanges, int, int) {
+	return p.dr, p.nx, p.ny
+}
+
+// updatePositive updates iv with the positive values in vs and returns
+// the number of non-positive ones.
+func updatePositive(iv *facet.Interval, vs []float64) int {
+	n := 0
+	for _, v := range vs {
+		if v > 0 {
+			iv.Update(v)
+		} else if v <= 0 {
+			n++
+		}
+	}
+	return n
+}
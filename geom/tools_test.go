@@ -3,6 +3,9 @@ package geom
 import (
 	"fmt"
 	"testing"
+
+	"github.com/vdobler/facet"
+	"gonum.org/v1/plot/plotter"
 )
 
 func TestCopyAesthetics(t *testing.T) {
@@ -20,3 +23,23 @@ func TestCopyAesthetics(t *testing.T) {
 	CopyAesthetics(&v, &h, addone)
 	fmt.Println(v.Alpha(3))
 }
+
+func TestRefLinePerPanel(t *testing.T) {
+	p := facet.NewPlot(1, 2, false, false)
+	p.ColLabels = []string{"A", "B"}
+	cols := []string{"A", "B", ""}
+	h := HLine{
+		Y:     plotter.Values{1, 2, 3},
+		Group: func(i int) facet.GroupID { return facet.GroupID{Col: cols[i]} },
+	}
+
+	for col, want := range []facet.Interval{{Min: 1, Max: 3}, {Min: 2, Max: 3}} {
+		got := h.PanelDataRange(p.Panels[0][col])[facet.YScale]
+		if got != want {
+			t.Errorf("panel %s: Y range %v, want %v", cols[col], got, want)
+		}
+	}
+	if got := h.DataRange()[facet.YScale]; got.Min != 1 || got.Max != 3 {
+		t.Errorf("overall Y range %v, want [1,3]", got)
+	}
+}
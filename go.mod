@@ -2,4 +2,8 @@ module github.com/vdobler/facet
 
 go 1.15
 
-require gonum.org/v1/plot v0.8.1
+require (
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
+	golang.org/x/image v0.0.0-20200618115811-c13761719519
+	gonum.org/v1/plot v0.8.1
+)
@@ -1 +1,243 @@
 package facet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/recorder"
+)
+
+var legendSizeTests = []struct {
+	position      string
+	maxWidth      vg.Length
+	width, height vg.Length
+}{
+	{"right", 100, 40, 78},
+	{"top", 200, 126, 30},
+	{"top", 100, 78, 54},
+	{"bottom", 50, 40, 78},
+}
+
+func TestLegendSize(t *testing.T) {
+	guides := []guideBox{
+		{width: 40, height: 30},
+		{width: 30, height: 20},
+		{width: 40, height: 20},
+	}
+	for i, tc := range legendSizeTests {
+		p := NewSimplePlot()
+		p.Style.Legend.Discrete.Pad = 4
+		p.Style.Legend.Position = tc.position
+		w, h := p.legendSize(guides, tc.maxWidth)
+		if w != tc.width || h != tc.height {
+			t.Errorf("%d. %s legend size = %vx%v, want %vx%v",
+				i, tc.position, w, h, tc.width, tc.height)
+		}
+	}
+}
+
+func TestLegendPosition(t *testing.T) {
+	for _, tc := range []struct {
+		position string
+		ok       bool
+	}{
+		{"", true}, {"right", true}, {"left", true}, {"top", true},
+		{"bottom", true}, {"inside", true}, {"none", true},
+		{"rigth", false}, {"Top", false},
+	} {
+		p := axisTestPlot(1, 1)
+		p.Style.Legend.Position = tc.position
+		c := draw.Canvas{Canvas: &recorder.Canvas{}, Rectangle: vg.Rectangle{Max: vg.Point{X: 400, Y: 300}}}
+		if err := p.Draw(c); (err == nil) != tc.ok {
+			t.Errorf("position %q: got error %v", tc.position, err)
+		}
+	}
+}
+
+func TestLogSizeGuideTicks(t *testing.T) {
+	p := NewSimplePlot()
+	s := p.Scales[SizeScale]
+	s.Trans = Log10Trans
+	s.Limit, s.Range = Interval{1, 1000}, Interval{1, 1000}
+
+	labels := []string{}
+	for _, tick := range p.guideTicks([]int{SizeScale}, p.tickerFor([]int{SizeScale})) {
+		if tick.Label != "" {
+			labels = append(labels, tick.Label)
+		}
+	}
+	if got := strings.Join(labels, " "); got != "1 10 100 1000" {
+		t.Errorf("log size guide labels %q, want \"1 10 100 1000\"", got)
+	}
+
+	// An explicit Ticker takes precedence and shapes stay discrete.
+	s.Ticker = DefaultTicks(3)
+	if _, ok := p.tickerFor([]int{SizeScale}).(DefaultTicks); !ok {
+		t.Errorf("explicit Ticker not used")
+	}
+	p.Scales[ShapeScale].Trans = Log10Trans
+	if _, ok := p.tickerFor([]int{ShapeScale}).(DiscreteTicks); !ok {
+		t.Errorf("shape guide not ticked discretely")
+	}
+}
+
+func TestLayoutKeys(t *testing.T) {
+	p := NewSimplePlot()
+	p.Style.Legend.Discrete.Size = 10
+	p.Style.Legend.Discrete.Columns = 2
+	p.Style.Legend.Discrete.Pad = 5
+	widths := []vg.Length{20, 30, 20, 20, 20}
+
+	// By column: 0, 1, 2 in first column; 3, 4 in second.
+	got := p.layoutKeys(widths, 10, 100)
+	want := []vg.Point{{X: 0, Y: 0}, {X: 0, Y: -15}, {X: 0, Y: -30}, {X: 40, Y: 0}, {X: 40, Y: -15}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("by column: key %d at %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// By row: 0, 1 in first row; 2, 3 in second; 4 in third.
+	p.Style.Legend.Discrete.ByRow = true
+	got = p.layoutKeys(widths, 10, 100)
+	want = []vg.Point{{X: 0, Y: 0}, {X: 30, Y: 0}, {X: 0, Y: -15}, {X: 30, Y: -15}, {X: 0, Y: -30}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("by row: key %d at %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestColorBarOrientation(t *testing.T) {
+	p := NewSimplePlot()
+	s := p.Scales[ColorScale]
+	s.Limit, s.Range = Interval{0, 10}, Interval{0, 10}
+	c := draw.Canvas{
+		Canvas:    &recorder.Canvas{},
+		Rectangle: vg.Rectangle{Max: vg.Point{X: 300, Y: 300}},
+	}
+	length := p.Style.Legend.Continuous.Length
+
+	r := p.drawColorBar(c, []int{ColorScale})
+	if h := r.Max.Y - r.Min.Y; h < length || h > length+20 {
+		t.Errorf("vertical bar has height %v, want about %v", h, length)
+	}
+	if r.Max.X-r.Min.X >= length {
+		t.Errorf("vertical bar too wide: %v", r)
+	}
+
+	p.Style.Legend.Continuous.Horizontal = true
+	r = p.drawColorBar(c, []int{ColorScale})
+	if w := r.Max.X - r.Min.X; w < length || w > length+30 {
+		t.Errorf("horizontal bar has width %v, want about %v", w, length)
+	}
+	if r.Max.Y-r.Min.Y >= length {
+		t.Errorf("horizontal bar too high: %v", r)
+	}
+	if r.Max.Y != 300 {
+		t.Errorf("horizontal bar not at top of canvas: %v", r)
+	}
+}
+
+// keyGeom is a Geom with data on the given scales.
+type keyGeom []int
+
+func (g keyGeom) DataRange() DataRanges {
+	dr := NewDataRanges()
+	for _, s := range g {
+		dr[s].Update(1)
+	}
+	return dr
+}
+
+func (g keyGeom) Draw(p *Panel) {}
+
+func TestKeyLayers(t *testing.T) {
+	p := NewSimplePlot()
+	color, fill, both := keyGeom{ColorScale}, keyGeom{FillScale}, keyGeom{ColorScale, FillScale}
+	p.Panels[0][0].Geoms = []Geom{fill, color, both}
+
+	if got := p.keyLayers([]int{ColorScale}); len(got) != 2 {
+		t.Errorf("color guide has %d layers, want 2", len(got))
+	}
+	if got := p.keyLayers([]int{FillScale}); len(got) != 2 {
+		t.Errorf("fill guide has %d layers, want 2", len(got))
+	}
+	if got := p.keyLayers([]int{ShapeScale}); len(got) != 0 {
+		t.Errorf("shape guide has %d layers, want 0", len(got))
+	}
+}
+
+func TestCombineGuidesWithGuideSpec(t *testing.T) {
+	newPlot := func() *Plot {
+		p := NewSimplePlot()
+		for _, s := range []int{ColorScale, ShapeScale, SizeScale} {
+			p.Scales[s].ScaleType = Discrete
+			p.Scales[s].Data = Interval{1, 3}
+			p.Scales[s].Limit = Interval{1, 3}
+		}
+		return p
+	}
+
+	p := newPlot()
+	if got := p.combineGuides(); len(got) != 1 {
+		t.Fatalf("default: got %v, want one combined guide", got)
+	}
+
+	p = newPlot()
+	p.Scales[SizeScale].Guide.Type = "none"
+	if got := p.combineGuides(); len(got) != 1 || containsInt(got[0], SizeScale) {
+		t.Errorf("hidden size: got %v", got)
+	}
+
+	p = newPlot()
+	p.Scales[ColorScale].Guide.Merge = "a"
+	p.Scales[ShapeScale].Guide.Merge = "a"
+	if got := p.combineGuides(); len(got) != 2 {
+		t.Errorf("merge key: got %v, want two guides", got)
+	}
+
+	p = newPlot()
+	p.Scales[ShapeScale].Guide.Format = func(x float64) string { return "s" }
+	if got := p.combineGuides(); len(got) != 2 {
+		t.Errorf("different labels: got %v, want two guides", got)
+	}
+
+	p = newPlot()
+	p.Scales[ColorScale].Guide.Merge = "a"
+	p.Scales[SizeScale].Guide.Merge = "b"
+	p.Scales[SizeScale].Guide.Order = 1
+	got := p.combineGuides()
+	p.orderGuides(got)
+	if len(got) != 3 || got[0][0] != SizeScale {
+		t.Errorf("order: got %v, want size guide first", got)
+	}
+}
+
+func TestBinnedColorBarLabels(t *testing.T) {
+	p := NewSimplePlot()
+	s := p.Scales[ColorScale]
+	s.Limit, s.Range = Interval{0, 10}, Interval{0, 10}
+	s.Binned = true
+	s.Breaks = []float64{2.5, 7.5}
+	s.Guide.Format = func(x float64) string { return fmt.Sprintf("%.1f%%", x) }
+	rec := &recorder.Canvas{}
+	c := draw.Canvas{
+		Canvas:    rec,
+		Rectangle: vg.Rectangle{Max: vg.Point{X: 300, Y: 300}},
+	}
+	p.drawColorBar(c, []int{ColorScale})
+
+	var labels []string
+	for _, a := range rec.Actions {
+		if fs, ok := a.(*recorder.FillString); ok {
+			labels = append(labels, fs.String)
+		}
+	}
+	if got, want := strings.Join(labels, " "), "2.5% 7.5%"; got != want {
+		t.Errorf("Got labels %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package facet
+
+import (
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// Legend keys
+
+// Key describes one key of a discrete guide: The aesthetics the key's data
+// value is mapped to by the scales shown in the guide. Aesthetics of scales
+// not shown in the guide are unset (nil or zero) and Alpha is 1 if the
+// Alpha scale is not shown.
+type Key struct {
+	Value  float64 // The data value of the key.
+	Scales []int   // The scales shown in the guide.
+
+	Color  color.Color
+	Fill   color.Color
+	Alpha  float64
+	Shape  draw.GlyphDrawer
+	Size   vg.Length
+	Dashes []vg.Length
+}
+
+// Shows reports whether the guide of k shows scale.
+func (k Key) Shows(scale int) bool {
+	return containsInt(k.Scales, scale)
+}
+
+// StrokeColor returns the color for lines, glyphs and text of the key:
+// The key's Color or def if unset, with the key's Alpha applied.
+func (k Key) StrokeColor(def color.Color) color.Color {
+	if k.Color != nil {
+		def = k.Color
+	}
+	if def == nil || k.Alpha == 1 {
+		return def
+	}
+	return withAlpha(def, k.Alpha)
+}
+
+// FillColor works like StrokeColor but for the key's Fill.
+func (k Key) FillColor(def color.Color) color.Color {
+	if k.Fill != nil {
+		def = k.Fill
+	}
+	if def == nil || k.Alpha == 1 {
+		return def
+	}
+	return withAlpha(def, k.Alpha)
+}
+
+// A KeyDrawer is a Geom which draws its own legend keys, e.g. a filled
+// rectangle for bars or a short line for paths. Geoms not implementing
+// KeyDrawer are shown by a glyph and/or a diagonal line.
+type KeyDrawer interface {
+	// DrawKey draws key into the key box r of c.
+	DrawKey(p *Plot, c draw.Canvas, r vg.Rectangle, key Key)
+}
+
+// key returns the Key for the data value v in the guide of scales.
+func (p *Plot) key(scales []int, v float64) Key {
+	key := Key{Value: v, Scales: scales, Alpha: 1}
+	if containsInt(scales, ColorScale) {
+		key.Color = p.MapColor(v, false)
+	}
+	if containsInt(scales, FillScale) {
+		key.Fill = p.MapColor(v, true)
+	}
+	if containsInt(scales, AlphaScale) {
+		key.Alpha = p.MapAlpha(v)
+	}
+	if containsInt(scales, ShapeScale) {
+		key.Shape = p.MapShape(level(v))
+	}
+	if containsInt(scales, SizeScale) {
+		key.Size = p.MapSize(v)
+	}
+	if containsInt(scales, StrokeScale) {
+		key.Dashes = p.MapStroke(level(v))
+	}
+	return key
+}
+
+// keyLayers returns the layers drawing the keys of the guide of scales:
+// For each layer (the geoms at the same position in the panels' Geoms)
+// which maps at least one of the scales the first such geom is returned.
+func (p *Plot) keyLayers(scales []int) []Geom {
+	var layers []Geom
+	seen := make(map[int]Geom)
+	for _, panels := range p.Panels {
+		for _, panel := range panels {
+			for i, geom := range panel.Geoms {
+				if _, ok := seen[i]; !ok && mapsAny(geom, scales) {
+					seen[i] = geom
+				}
+			}
+		}
+	}
+	for i := 0; len(layers) < len(seen); i++ {
+		if geom, ok := seen[i]; ok {
+			layers = append(layers, geom)
+		}
+	}
+	return layers
+}
+
+// mapsAny reports whether geom has data on at least one of the scales.
+func mapsAny(geom Geom, scales []int) bool {
+	dr := geom.DataRange()
+	for _, s := range scales {
+		if !math.IsNaN(dr[s].Min) {
+			return true
+		}
+	}
+	return false
+}
+
+// drawKey draws key into r by stacking the keys of all layers.
+func (p *Plot) drawKey(c draw.Canvas, r vg.Rectangle, key Key, layers []Geom) {
+	drawnDefault := false
+	for _, geom := range layers {
+		if kd, ok := geom.(KeyDrawer); ok {
+			kd.DrawKey(p, c, r, key)
+			continue
+		}
+		if !drawnDefault {
+			p.drawDefaultKey(c, r, key)
+			drawnDefault = true
+		}
+	}
+	if len(layers) == 0 {
+		p.drawDefaultKey(c, r, key)
+	}
+}
+
+// drawDefaultKey draws key as a glyph and/or a diagonal line.
+func (p *Plot) drawDefaultKey(c draw.Canvas, r vg.Rectangle, key Key) {
+	col := key.StrokeColor(p.Style.GeomDefault.Color)
+	if key.Fill != nil && key.Color == nil {
+		col = key.FillColor(nil)
+	}
+
+	showStroke := key.Shows(StrokeScale)
+	if showStroke {
+		lsty := draw.LineStyle{
+			Color:  col,
+			Width:  1,
+			Dashes: key.Dashes,
+		}
+		c.StrokeLine2(lsty, r.Min.X, r.Min.Y, r.Max.X, r.Max.Y)
+	}
+
+	shape := draw.GlyphDrawer(draw.CircleGlyph{})
+	if key.Shows(ShapeScale) {
+		shape = key.Shape
+	}
+	size := (r.Max.X - r.Min.X) / 5
+	if key.Shows(SizeScale) {
+		size = key.Size
+	}
+	// Do not draw the shape if not needed.
+	showGlyph := key.Shows(ShapeScale) || key.Shows(FillScale) || key.Shows(SizeScale) ||
+		key.Shows(ColorScale) || (key.Shows(AlphaScale) && !showStroke)
+	if shape != nil && showGlyph {
+		gsty := draw.GlyphStyle{
+			Color:  col,
+			Radius: size,
+			Shape:  shape,
+		}
+		c.DrawGlyph(gsty, vg.Point{X: (r.Min.X + r.Max.X) / 2, Y: (r.Min.Y + r.Max.Y) / 2})
+	}
+}
@@ -0,0 +1,381 @@
+package facet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/recorder"
+)
+
+// ----------------------------------------------------------------------------
+// Legend layout
+
+// guideBox is one guide (a combination of scales) together with the
+// size it covers when drawn.
+type guideBox struct {
+	scales        []int
+	width, height vg.Length
+}
+
+// horizontalLegend reports whether the keys of discrete guides are
+// laid out horizontally.
+func (p *Plot) horizontalLegend() bool {
+	pos := p.Style.Legend.Position
+	return pos == "top" || pos == "bottom"
+}
+
+// measureGuides determines the size of all guides to draw by drawing
+// them onto a recording canvas of the same size as c.
+func (p *Plot) measureGuides(c draw.Canvas) []guideBox {
+	if p.Style.Legend.Position == "none" || !p.needGuides() {
+		return nil
+	}
+
+	mc := draw.Canvas{Canvas: &recorder.Canvas{}, Rectangle: c.Rectangle}
+	var guides []guideBox
+	combos := p.combineGuides()
+	p.orderGuides(combos)
+	for _, combo := range combos {
+		r := p.drawGuides(mc, combo)
+		guides = append(guides, guideBox{
+			scales: combo,
+			width:  r.Max.X - r.Min.X,
+			height: r.Max.Y - r.Min.Y,
+		})
+	}
+	return guides
+}
+
+// legendSize returns the size of the legend made from guides: The guides
+// are stacked vertically or, for horizontal legends, placed side by side
+// and wrapped into rows not wider than maxWidth.
+func (p *Plot) legendSize(guides []guideBox, maxWidth vg.Length) (width, height vg.Length) {
+	pad := p.Style.Legend.Discrete.Pad
+	if !p.horizontalLegend() {
+		for i, g := range guides {
+			width = maxLength(width, g.width)
+			if i > 0 {
+				height += pad
+			}
+			height += g.height
+		}
+		return width, height
+	}
+
+	var x, rowHeight vg.Length
+	for _, g := range guides {
+		if x > 0 && x+g.width > maxWidth {
+			height += rowHeight + pad
+			x, rowHeight = 0, 0
+		}
+		x += g.width + 2*pad
+		width = maxLength(width, x-2*pad)
+		rowHeight = maxLength(rowHeight, g.height)
+	}
+	return width, height + rowHeight
+}
+
+// drawLegend draws guides into c with the top left corner of the legend
+// at (x0, y0) using the same layout as legendSize.
+func (p *Plot) drawLegend(c draw.Canvas, guides []guideBox, x0, y0, maxWidth vg.Length) {
+	pad := p.Style.Legend.Discrete.Pad
+	x, y, rowHeight := x0, y0, vg.Length(0)
+	for _, g := range guides {
+		gc := c
+		if p.horizontalLegend() {
+			if x > x0 && x-x0+g.width > maxWidth {
+				x, y = x0, y-rowHeight-pad
+				rowHeight = 0
+			}
+			gc.Min.X, gc.Max.X, gc.Max.Y = x, x0+maxWidth, y
+			p.drawGuides(gc, g.scales)
+			x += g.width + 2*pad
+			rowHeight = maxLength(rowHeight, g.height)
+		} else {
+			gc.Min.X, gc.Max.Y = x, y
+			p.drawGuides(gc, g.scales)
+			y -= g.height + pad
+		}
+	}
+}
+
+// placeLegend draws the legend on the side of c selected by
+// p.Style.Legend.Position and returns the part of c which remains for
+// the panels. Inside legends are not drawn here but after the panels
+// by drawInsideLegend.
+func (p *Plot) placeLegend(c draw.Canvas, guides []guideBox) draw.Canvas {
+	if len(guides) == 0 {
+		return c
+	}
+	pad := p.Style.Legend.Discrete.Pad
+	availWidth, availHeight := c.Max.X-c.Min.X, c.Max.Y-c.Min.Y
+	width, height := p.legendSize(guides, availWidth)
+	p.checkLegendFits(width, height, availWidth, availHeight)
+
+	switch p.Style.Legend.Position {
+	case "inside":
+		return c
+	case "left":
+		y0 := c.Min.Y + (c.Max.Y-c.Min.Y+height)/2
+		p.drawLegend(c, guides, c.Min.X, minLength(y0, c.Max.Y), width)
+		c.Min.X += width + pad
+	case "top":
+		x0 := c.Min.X + (availWidth-width)/2
+		p.drawLegend(c, guides, x0, c.Max.Y, width)
+		c.Max.Y -= height + pad
+	case "bottom":
+		x0 := c.Min.X + (availWidth-width)/2
+		p.drawLegend(c, guides, x0, c.Min.Y+height, width)
+		c.Min.Y += height + pad
+	default: // "" or "right"
+		y0 := c.Min.Y + (c.Max.Y-c.Min.Y+height)/2
+		p.drawLegend(c, guides, c.Max.X-width, minLength(y0, c.Max.Y), width)
+		c.Max.X -= width + pad
+	}
+	return c
+}
+
+// checkLegendPosition returns an error if p.Style.Legend.Position is not
+// one of the known positions.
+func (p *Plot) checkLegendPosition() error {
+	switch p.Style.Legend.Position {
+	case "", "right", "left", "top", "bottom", "inside", "none":
+		return nil
+	}
+	return fmt.Errorf("facet: unknown legend position %q", p.Style.Legend.Position)
+}
+
+// drawInsideLegend draws an inside legend onto the area covered by the
+// panels.
+func (p *Plot) drawInsideLegend(c draw.Canvas, guides []guideBox) {
+	if len(guides) == 0 || p.Style.Legend.Position != "inside" {
+		return
+	}
+	area := p.panelArea()
+	width, height := p.legendSize(guides, area.Max.X-area.Min.X)
+	p.checkLegendFits(width, height, area.Max.X-area.Min.X, area.Max.Y-area.Min.Y)
+
+	// Place the point (X,Y) of the legend box at (X,Y) of the panel area.
+	fx, fy := vg.Length(p.Style.Legend.Inside.X), vg.Length(p.Style.Legend.Inside.Y)
+	x0 := area.Min.X + fx*(area.Max.X-area.Min.X) - fx*width
+	y0 := area.Min.Y + fy*(area.Max.Y-area.Min.Y) + (1-fy)*height
+
+	p.drawLegend(c, guides, x0, y0, width)
+}
+
+// panelArea returns the rectangle covered by all panels of p.
+func (p *Plot) panelArea() vg.Rectangle {
+	bottomLeft := p.Panels[p.Rows-1][0].Canvas.Rectangle
+	topRight := p.Panels[0][p.Cols-1].Canvas.Rectangle
+	return vg.Rectangle{Min: bottomLeft.Min, Max: topRight.Max}
+}
+
+// layoutKeys returns the offsets of the top left corners of the keys of a
+// discrete guide relative to the top left corner of the guide. The keys
+// have the given widths and a height of boxSize and are arranged in a grid
+// as determined by p.Style.Legend.Discrete.
+func (p *Plot) layoutKeys(widths []vg.Length, boxSize, maxWidth vg.Length) []vg.Point {
+	n := len(widths)
+	d := p.Style.Legend.Discrete
+	pad := d.Pad
+	offsets := make([]vg.Point, n)
+	if n == 0 {
+		return offsets
+	}
+
+	var rows, cols int
+	switch {
+	case d.Columns > 0:
+		cols = d.Columns
+		rows = (n + cols - 1) / cols
+	case d.Rows > 0:
+		rows = d.Rows
+		cols = (n + rows - 1) / rows
+	case p.horizontalLegend():
+		// A single row wrapped at maxWidth.
+		var x, y vg.Length
+		for i, w := range widths {
+			if x > 0 && x+w > maxWidth {
+				x, y = 0, y-boxSize-pad
+			}
+			offsets[i] = vg.Point{X: x, Y: y}
+			x += w + 2*pad
+		}
+		return offsets
+	default:
+		rows, cols = n, 1
+	}
+
+	cell := func(i int) (row, col int) {
+		if d.ByRow {
+			return i / cols, i % cols
+		}
+		return i % rows, i / rows
+	}
+	colX := make([]vg.Length, cols+1)
+	for i, w := range widths {
+		_, col := cell(i)
+		colX[col+1] = maxLength(colX[col+1], w+2*pad)
+	}
+	for col := 1; col <= cols; col++ {
+		colX[col] += colX[col-1]
+	}
+	for i := range widths {
+		row, col := cell(i)
+		offsets[i] = vg.Point{X: colX[col], Y: -vg.Length(row) * (boxSize + pad)}
+	}
+	return offsets
+}
+
+// orderGuides sorts guides according to the Order of the scales' Guide
+// and p.Style.Legend.Order.
+func (p *Plot) orderGuides(guides [][]int) {
+	// Guides are ranked by class (0: Guide.Order set, 1: listed in
+	// Style.Legend.Order, 2: others) and the order inside the class.
+	rank := func(combo []int) (int, int) {
+		order := 0
+		for _, s := range combo {
+			if o := p.Scales[s].Guide.Order; o != 0 && (order == 0 || o < order) {
+				order = o
+			}
+		}
+		if order != 0 {
+			return 0, order
+		}
+		for i, s := range p.Style.Legend.Order {
+			if containsInt(combo, s) {
+				return 1, i
+			}
+		}
+		return 2, 0
+	}
+	sort.SliceStable(guides, func(i, j int) bool {
+		ci, oi := rank(guides[i])
+		cj, oj := rank(guides[j])
+		return ci < cj || (ci == cj && oi < oj)
+	})
+}
+
+// checkLegendFits warns if a legend of the given size does not fit into
+// the available space.
+func (p *Plot) checkLegendFits(width, height, availWidth, availHeight vg.Length) {
+	if width > availWidth || height > availHeight {
+		p.Warnf("Legend of size %.0fx%.0f does not fit into %.0fx%.0f, consider more legend columns or rows",
+			width, height, availWidth, availHeight)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Guide specification
+
+// Guide is an explicit specification of the guide of a scale. The zero
+// value leaves all decisions to the default heuristics.
+type Guide struct {
+	// Type of the guide: "legend" (discrete keys), "colorbar" (only
+	// for Color, Fill and Alpha scales) or "none" to suppress the
+	// guide. The empty string selects the type automatically.
+	Type string
+
+	// Merge is the merge key of the guide: If one of two scales has a
+	// Merge key their guides are combined iff the keys are equal.
+	// Scales without Merge key are combined if canCombineScales allows.
+	Merge string
+
+	// Title overrides the Title of the scale in the guide.
+	Title string
+
+	// Format, if non-nil, formats the labels of the guide. It overrides
+	// the Format of the scale.
+	Format func(x float64) string
+
+	// Order determines the position of the guide in the legend: Guides
+	// with non-zero Order come first, sorted by Order. The remaining ones
+	// follow as determined by Style.Legend.Order.
+	Order int
+
+	// Reverse the order of the keys or the direction of the color bar.
+	Reverse bool
+}
+
+// guideTitle returns the title of the guide of scale s.
+func (p *Plot) guideTitle(s int) string {
+	if title := p.Scales[s].Guide.Title; title != "" {
+		return title
+	}
+	return p.Scales[s].Title
+}
+
+// guideFormat returns the label format of the guide of scale s: The
+// Format of its Guide or the Format of the scale itself.
+func (p *Plot) guideFormat(s int) func(x float64) string {
+	if format := p.Scales[s].Guide.Format; format != nil {
+		return format
+	}
+	return p.Scales[s].Format
+}
+
+// guideLabel returns the label of the tick in the guide of scale s.
+// Unlabeled ticks stay unlabeled.
+func (p *Plot) guideLabel(s int, tick plot.Tick) string {
+	if format := p.guideFormat(s); format != nil && tick.Label != "" {
+		return format(tick.Value)
+	}
+	return tick.Label
+}
+
+// guideValueLabel returns the label of the value x (which need not be a
+// tick) in the guide of the combined scales: Like in guideTicks the format
+// of the first scale with a format is used, otherwise x is printed as is.
+func (p *Plot) guideValueLabel(scales []int, x float64) string {
+	tick := plot.Tick{Value: x, Label: strconv.FormatFloat(x, 'g', -1, 64)}
+	for _, s := range scales {
+		if p.guideFormat(s) != nil {
+			return p.guideLabel(s, tick)
+		}
+	}
+	return tick.Label
+}
+
+// guideTicks returns the ticks of the guide of the combined scales as
+// generated by ticker over the Limit of the first scale and labeled by the
+// first scale with a format.
+func (p *Plot) guideTicks(scales []int, ticker plot.Ticker) []plot.Tick {
+	scale := p.Scales[scales[0]]
+	ticks := ticker.Ticks(scale.Limit.Min, scale.Limit.Max)
+	for _, s := range scales {
+		if p.guideFormat(s) == nil {
+			continue
+		}
+		for i := range ticks {
+			ticks[i].Label = p.guideLabel(s, ticks[i])
+		}
+		break
+	}
+	return ticks
+}
+
+// guideType returns the explicit type of the guide of the combined scales
+// or the empty string.
+func (p *Plot) guideType(scales []int) string {
+	for _, s := range scales {
+		if t := p.Scales[s].Guide.Type; t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// reverseGuide reports whether the Guide of one of the combined scales
+// requests reverse order.
+func (p *Plot) reverseGuide(scales []int) bool {
+	for _, s := range scales {
+		if p.Scales[s].Guide.Reverse {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package facet
+
+import (
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// Manual and identity scales
+
+// ManualScales contains explicit level to aesthetic value tables. A level
+// is the data value of the aesthetic rounded to the nearest integer.
+// A non-nil table replaces the default mapping of the corresponding scale
+// (the ColorMap, FillMap, plotutil.Shape, plotutil.Dashes or the Size scale's
+// Trans). Colors and sizes of levels not found in a table are handled like
+// values outside of the scale's Range, missing shapes are not drawn at all
+// and missing strokes are drawn solid.
+type ManualScales struct {
+	Color  map[int]color.Color
+	Fill   map[int]color.Color
+	Shape  map[int]draw.GlyphDrawer
+	Stroke map[int][]vg.Length
+	Size   map[int]vg.Length
+}
+
+// level turns the data value v into a manual scale level.
+func level(v float64) int {
+	return int(math.Round(v))
+}
+
+// ColorValue encodes c as a data value suitable for an aesthetic mapped on
+// a Color or Fill scale with Identity set: The non-alpha-premultiplied
+// color components are packed as 0xRRGGBBAA.
+func ColorValue(c color.Color) float64 {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return float64(uint32(n.R)<<24 | uint32(n.G)<<16 | uint32(n.B)<<8 | uint32(n.A))
+}
+
+// valueColor is the inverse of ColorValue.
+func valueColor(v float64) color.Color {
+	u := uint32(v)
+	return color.NRGBA{
+		R: uint8(u >> 24),
+		G: uint8(u >> 16),
+		B: uint8(u >> 8),
+		A: uint8(u),
+	}
+}
+
+// MapShape maps the discrete data value v to a glyph via p's manual
+// Shape table or plotutil.Shape.
+func (p *Plot) MapShape(v int) draw.GlyphDrawer {
+	if p.Manual.Shape != nil {
+		if shape, ok := p.Manual.Shape[v]; ok {
+			return shape
+		}
+		return nil
+	}
+	return plotutil.Shape(v)
+}
+
+// MapStroke maps the discrete data value v to a dash pattern via p's manual
+// Stroke table or plotutil.Dashes.
+func (p *Plot) MapStroke(v int) []vg.Length {
+	if p.Manual.Stroke != nil {
+		return p.Manual.Stroke[v]
+	}
+	return plotutil.Dashes(v)
+}
+
+// hasGuide reports whether the scale s needs a guide: Scales without data,
+// identity scales and scales with a Guide of Type "none" do not have a guide.
+func (p *Plot) hasGuide(s int) bool {
+	scale := p.Scales[s]
+	return scale.HasData() && !scale.Identity && scale.Guide.Type != "none"
+}
+
+// sameColorTable reports whether the two manual color tables a and b
+// are identical or one of them is unset.
+func sameColorTable(a, b map[int]color.Color) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	for k, c := range a {
+		d, ok := b[k]
+		if !ok || c != d {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package facet
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestColorValue(t *testing.T) {
+	for _, c := range []color.NRGBA{
+		{0, 0, 0, 0},
+		{0xff, 0xff, 0xff, 0xff},
+		{0x12, 0x34, 0x56, 0x78},
+		{0xe6, 0x9f, 0x00, 0xff},
+	} {
+		v := ColorValue(c)
+		if got := valueColor(v); got != c {
+			t.Errorf("valueColor(ColorValue(%v)) = %v", c, got)
+		}
+	}
+}
+
+func TestManualColor(t *testing.T) {
+	p := NewSimplePlot()
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	p.Manual.Fill = map[int]color.Color{1: red}
+	if got := p.MapColor(1.2, true); got != red {
+		t.Errorf("MapColor(1.2) = %v, want %v", got, red)
+	}
+	if got := p.MapColor(2, true); got != (color.Gray{0x7f}) {
+		t.Errorf("MapColor(2) = %v, want gray", got)
+	}
+}
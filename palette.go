@@ -29,17 +29,21 @@ type Rainbow struct {
 
 // At returns the color mapped for x.
 func (r *Rainbow) At(x float64) (color.Color, error) {
-	h := r.StartHue + (1-r.HueGap)*(x-r.min)/(r.max-r.min)
+	return r.hsva((x - r.min) / (r.max - r.min)), nil
+}
+
+// hsva returns the color for the fraction t of the used hue space.
+func (r *Rainbow) hsva(t float64) palette.HSVA {
+	h := r.StartHue + (1-r.HueGap)*t
 	if h > 1 {
 		h = h - math.Trunc(h)
 	}
-	c := palette.HSVA{
+	return palette.HSVA{
 		H: h,
 		S: r.Saturation,
 		V: r.Value,
 		A: r.alpha,
 	}
-	return c, nil
 }
 
 // Max returns the current maximum value of the ColorMap.
@@ -86,16 +90,174 @@ func (r *Rainbow) Palette(colors int) palette.Palette {
 	return r
 }
 
-// Colors implements palette.Palette.Colors.
+// Colors implements palette.Palette.Colors. The colors are evenly spaced
+// over the same hues At uses for the interval [Min, Max].
 func (r *Rainbow) Colors() []color.Color {
 	colors := make([]color.Color, r.Number)
 	for i := range colors {
-		colors[i] = palette.HSVA{
-			H: float64(i) / float64(r.Number+1),
-			S: r.Saturation,
-			V: r.Value,
-			A: r.alpha,
+		x := 0.0
+		if r.Number > 1 {
+			x = float64(i) / float64(r.Number-1)
+		}
+		colors[i] = r.hsva(x)
+	}
+	return colors
+}
+
+// HCL is a color map of evenly spaced hues of constant chroma and
+// luminance in the CIE-LCh(uv) color space. As all colors have the same
+// perceived brightness none of them stands out. This is the default hue
+// palette of ggplot2 (scale_colour_hue).
+type HCL struct {
+	Chroma    float64 // Chroma of the generated colors.
+	Luminance float64 // Luminance of the generated colors (0 to 100).
+	StartHue  float64 // StartHue is the hue (in degrees) used for the Min value.
+	EndHue    float64 // EndHue is the hue (in degrees) used for the Max value.
+	Number    int     // Number of colors generated by the Colors method.
+
+	min, max, alpha float64
+}
+
+var _ palette.ColorMap = (*HCL)(nil)
+var _ palette.Palette = (*HCL)(nil)
+
+// NewHCL returns a HCL color map with ggplot2's default chroma, luminance
+// and hues, i.e. the full circle from 15° to 375°.
+func NewHCL() *HCL {
+	return &HCL{
+		Chroma:    100,
+		Luminance: 65,
+		StartHue:  15,
+		EndHue:    375,
+		min:       0,
+		max:       1,
+		alpha:     1,
+	}
+}
+
+// At returns the color mapped for x. The hues are spaced like in Colors,
+// so x = Min maps to the first and x = Max to the last of the Colors.
+func (h *HCL) At(x float64) (color.Color, error) {
+	t := (x - h.min) / (h.max - h.min)
+	return h.lch(h.StartHue + t*h.span()), nil
+}
+
+// span returns the difference between the hues used for Min and Max. If
+// the hues cover a full circle the last hue is dropped as it would
+// duplicate the first one, exactly like ggplot2's hue_pal does: For
+// Number colors the hue of Max is the one of the last of the Colors. If
+// Number is unset one twelfth of the circle is left out.
+func (h *HCL) span() float64 {
+	span := h.EndHue - h.StartHue
+	if span == 0 || math.Mod(math.Abs(span), 360) >= 1 {
+		return span
+	}
+	n := h.Number
+	if n < 2 {
+		n = 12
+	}
+	return span * float64(n-1) / float64(n)
+}
+
+// Max returns the current maximum value of the ColorMap.
+func (h *HCL) Max() float64 {
+	return h.max
+}
+
+// SetMax sets the maximum value of the ColorMap.
+func (h *HCL) SetMax(max float64) {
+	h.max = max
+}
+
+// Min returns the current minimum value of the ColorMap.
+func (h *HCL) Min() float64 {
+	return h.min
+}
+
+// SetMin sets the minimum value of the ColorMap.
+func (h *HCL) SetMin(min float64) {
+	h.min = min
+}
+
+// Alpha returns the opacity value of the ColorMap.
+func (h *HCL) Alpha() float64 {
+	return h.alpha
+}
+
+// SetAlpha sets the opacity value of the ColorMap. It panics if alpha is
+// not between zero and one.
+func (h *HCL) SetAlpha(alpha float64) {
+	if alpha < 0 || alpha > 1 {
+		panic(alpha)
+	}
+	h.alpha = alpha
+}
+
+// Palette returns a copy of h with Number set to colors as a
+// palette.Palette. h itself is left unchanged so that At does not depend
+// on previous calls to Palette.
+func (h *HCL) Palette(colors int) palette.Palette {
+	p := *h
+	p.Number = colors
+	return &p
+}
+
+// Colors implements palette.Palette.Colors. The colors are evenly spaced
+// over the hues of At, see span.
+func (h *HCL) Colors() []color.Color {
+	colors := make([]color.Color, h.Number)
+	span := h.span()
+	for i := range colors {
+		var t float64
+		if h.Number > 1 {
+			t = float64(i) / float64(h.Number-1)
 		}
+		colors[i] = h.lch(h.StartHue + t*span)
 	}
 	return colors
 }
+
+// lch converts the hue (in degrees) with h's chroma and luminance from
+// CIE-LCh(uv) to sRGB (D65 white point).
+func (h *HCL) lch(hue float64) color.Color {
+	const (
+		xn, yn, zn = 95.047, 100.000, 108.883
+		un         = 4 * xn / (xn + 15*yn + 3*zn)
+		vn         = 9 * yn / (xn + 15*yn + 3*zn)
+	)
+
+	L := h.Luminance
+	if L <= 0 {
+		return color.NRGBA{A: uint8(math.Round(255 * h.alpha))}
+	}
+	rad := hue * math.Pi / 180
+	u, v := h.Chroma*math.Cos(rad), h.Chroma*math.Sin(rad)
+
+	// CIE-Luv to CIE-XYZ
+	var Y float64
+	if L > 8 {
+		Y = yn * math.Pow((L+16)/116, 3)
+	} else {
+		Y = yn * L * math.Pow(3.0/29, 3)
+	}
+	up, vp := u/(13*L)+un, v/(13*L)+vn
+	X := Y * 9 * up / (4 * vp)
+	Z := Y * (12 - 3*up - 20*vp) / (4 * vp)
+	X, Y, Z = X/100, Y/100, Z/100
+
+	// CIE-XYZ to linear RGB to sRGB
+	gamma := func(c float64) uint8 {
+		if c <= 0.0031308 {
+			c *= 12.92
+		} else {
+			c = 1.055*math.Pow(c, 1/2.4) - 0.055
+		}
+		return uint8(math.Round(255 * math.Max(0, math.Min(1, c))))
+	}
+	return color.NRGBA{
+		R: gamma(3.2404542*X - 1.5371385*Y - 0.4985314*Z),
+		G: gamma(-0.9692660*X + 1.8760108*Y + 0.0415560*Z),
+		B: gamma(0.0556434*X - 0.2040259*Y + 1.0572252*Z),
+		A: uint8(math.Round(255 * h.alpha)),
+	}
+}
@@ -0,0 +1,63 @@
+package facet
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestHCLColors(t *testing.T) {
+	// The colors of ggplot2's scales::hue_pal()(3).
+	want := []color.NRGBA{
+		{0xf8, 0x76, 0x6d, 0xff},
+		{0x00, 0xba, 0x38, 0xff},
+		{0x61, 0x9c, 0xff, 0xff},
+	}
+	h := NewHCL()
+	got := h.Palette(3).Colors()
+	for i := range want {
+		c := got[i].(color.NRGBA)
+		if absDiff(c.R, want[i].R) > 1 || absDiff(c.G, want[i].G) > 1 || absDiff(c.B, want[i].B) > 1 {
+			t.Errorf("color %d = %v, want %v", i, c, want[i])
+		}
+	}
+}
+
+func TestHCLAt(t *testing.T) {
+	for _, h := range []*HCL{NewHCL(), {Chroma: 100, Luminance: 65, StartHue: 0, EndHue: 270}} {
+		h.SetMin(0)
+		h.SetMax(1)
+		h.SetAlpha(1)
+		h.Number = 5
+		colors := h.Palette(5).Colors()
+		for i, want := range colors {
+			got, _ := h.At(float64(i) / 4)
+			if got != want {
+				t.Errorf("%g-%g: At(%d/4) = %v, want %v", h.StartHue, h.EndHue, i, got, want)
+			}
+		}
+		if colors[0] == colors[4] {
+			t.Errorf("%g-%g: same color for min and max", h.StartHue, h.EndHue)
+		}
+	}
+
+	// Without Number a full circle still maps Min and Max differently.
+	h := NewHCL()
+	min, _ := h.At(0)
+	max, _ := h.At(1)
+	if min == max {
+		t.Errorf("same color %v for min and max", min)
+	}
+
+	// Palette does not change the colors of At.
+	h.Palette(3)
+	if got, _ := h.At(1); got != max || h.Number != 0 {
+		t.Errorf("after Palette(3): At(1) = %v, want %v", got, max)
+	}
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -17,19 +17,51 @@ type Panel struct {
 	Geoms  []Geom
 	Canvas draw.Canvas
 	Scales [numScales]*Scale
+
+	// Row and Col are the position of the panel in the Plot.
+	Row, Col int
+}
+
+var _ Grouper = (*Panel)(nil)
+
+// Group returns the group shown in p, i.e. the row and column labels
+// of the panel.
+func (p *Panel) Group() GroupID {
+	return GroupID{Row: p.Plot.RowLabels[p.Row], Col: p.Plot.ColLabels[p.Col]}
+}
+
+// Shows reports whether p shows group g. Empty fields of g match any
+// label, e.g. GroupID{Col: "A"} is shown in all panels of column "A".
+func (p *Panel) Shows(g GroupID) bool {
+	pg := p.Group()
+	return (g.Row == "" || g.Row == pg.Row) && (g.Col == "" || g.Col == pg.Col)
 }
 
 func (p *Panel) InRangeXY(x, y float64) bool {
 	return p.Scales[XScale].InRange(x) && p.Scales[YScale].InRange(y)
 }
 
-// MapXY maps the data coordinate (x,y) to a canvas point.
+// PlaceXY maps the data coordinate (x,y) to a canvas point after applying
+// the out-of-bounds policies of p's X and Y scale. It reports false if x
+// or y is censored.
+func (p *Panel) PlaceXY(x, y float64) (vg.Point, bool) {
+	x, okx := p.Scales[XScale].ApplyOOB(x)
+	y, oky := p.Scales[YScale].ApplyOOB(y)
+	if !okx || !oky {
+		return vg.Point{}, false
+	}
+	return p.MapXY(x, y), true
+}
+
+// MapXY maps the data coordinate (x,y) to a canvas point. The View of
+// the X and Y scale is mapped to the panel's canvas, points outside of
+// the View lie outside of the canvas.
 func (p *Panel) MapXY(x, y float64) vg.Point {
 	xs, ys := p.Scales[XScale], p.Scales[YScale]
 	cx := Interval{float64(p.Canvas.Min.X), float64(p.Canvas.Max.X)}
 	cy := Interval{float64(p.Canvas.Min.Y), float64(p.Canvas.Max.Y)}
-	xu := xs.Trans.Trans(xs.Range, cx, x)
-	yu := ys.Trans.Trans(ys.Range, cy, y)
+	xu := xs.Trans.Trans(xs.View(), cx, x)
+	yu := ys.Trans.Trans(ys.View(), cy, y)
 	return vg.Point{X: vg.Length(xu), Y: vg.Length(yu)}
 }
 
@@ -43,7 +75,23 @@ func (p *Panel) MapColor(v float64) color.Color {
 	return p.Plot.MapColor(v, false)
 }
 
+// MapAlpha maps a data value v to an opacity by calling p.Plot.MapAlpha.
+func (p *Panel) MapAlpha(v float64) float64 {
+	return p.Plot.MapAlpha(v)
+}
+
 // MapFill maps a data value v to a color by calling p.Plot.MapColor(v,true).
 func (p *Panel) MapFill(v float64) color.Color {
 	return p.Plot.MapColor(v, true)
 }
+
+// MapShape maps a discrete data value v to a glyph by calling p.Plot.MapShape.
+func (p *Panel) MapShape(v int) draw.GlyphDrawer {
+	return p.Plot.MapShape(v)
+}
+
+// MapStroke maps a discrete data value v to a dash pattern by calling
+// p.Plot.MapStroke.
+func (p *Panel) MapStroke(v int) []vg.Length {
+	return p.Plot.MapStroke(v)
+}
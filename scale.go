@@ -29,7 +29,10 @@
 //      The plot area is clipped below 15.
 //      Ticks stay the same, only 20 and 30 are drawn.
 //
-// Being able to limit the Range is useful to "zoom in" into a plot.
+// Being able to limit the Range is useful to "zoom in" into a plot but data
+// outside the Range is handled by the scale's OOB policy, i.e. dropped by
+// default. The Zoom window of X and Y scales zooms in without dropping data
+// like ggplot2's coord_cartesian.
 // Expanding the range beyond the Limit is useful for the Size scale:
 // If your Limits are [20, 40] and you map that directly to point radius
 // from [2px, 20px] a point representing a data value of 20 will be
@@ -50,10 +53,13 @@ package facet
 
 import (
 	"fmt"
+	"image/color"
 	"math"
+	"sort"
 	"time"
 
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
 )
 
 // ----------------------------------------------------------------------------
@@ -90,12 +96,59 @@ type Scale struct {
 	// ScaleType determines the fundamental nature of the scale.
 	ScaleType ScaleType
 
+	// Identity scales use the data value directly as the aesthetic value
+	// and do not draw a guide: Color and Fill values are packed colors
+	// (see ColorValue), Size values are lengths in points.
+	Identity bool
+
+	// Midpoint turns a Color or Fill scale into a diverging scale if it
+	// is not NaN: The two halves [Range.Min, Midpoint] and
+	// [Midpoint, Range.Max] are mapped independently to [0, 0.5] and
+	// [0.5, 1] so that Midpoint is mapped to the center of the ColorMap.
+	Midpoint float64
+
+	// Binned turns a continuous Color or Fill scale into a binned (stepped)
+	// scale where all values in one bin are mapped to the same color.
+	Binned bool
+
+	// Breaks are the inner bin boundaries of a binned scale. If empty
+	// the major ticks of the scale's Ticker are used as breaks.
+	Breaks []float64
+
+	// Guide specifies the guide of an aesthetic scale.
+	Guide Guide
+
+	// OOB is the out-of-bounds policy for data values outside of the
+	// Range of this scale.
+	OOB OOB
+
+	// Zoom is the coordinate zoom window of an X or Y scale, i.e. the
+	// part of the data space shown in the panels. Unlike a Range narrower
+	// than the Limit zooming keeps all data: Autoscaling, stats and the
+	// OOB policy work on the full data and geoms are clipped geometrically
+	// to the panel when drawn. Unset (NaN) edges are not zoomed.
+	Zoom Interval
+
+	// NAColor is the color of censored and NaN data values of a Color or
+	// Fill scale. A nil NAColor drops such values.
+	NAColor color.Color
+
+	// NAValue is the aesthetic value of censored and NaN data values of
+	// a Size (in points) or Alpha (opacity) scale. A NaN NAValue drops
+	// such values.
+	NAValue float64
+
 	// Autoscaling can be used to control autoscaling of this scale.
 	Autoscaling
 
 	// Ticker is responsible for generating the ticks.
 	Ticker plot.Ticker
 
+	// Format, if non-nil, formats the labels of the ticks generated by
+	// the Ticker, e.g. SIFormat("B") or DurationFormat(time.Second).
+	// Unlabeled (minor) ticks stay unlabeled.
+	Format func(x float64) string
+
 	// Values contains the nominal values. TODO: replace by Ticker
 	Values []string
 
@@ -103,6 +156,13 @@ type Scale struct {
 	TimeFmt string
 	// T0 is the reference time and timezone
 	T0 time.Time
+
+	// bins are the edges of a binned scale computed during Prepare.
+	bins []float64
+
+	// colors are the colors of the levels of a discrete Color or Fill
+	// scale computed during Prepare.
+	colors []color.Color
 }
 
 // NewScale returns a new scale with all intervalls unset, an identitiy
@@ -112,7 +172,12 @@ func NewScale() *Scale {
 		Limit: UnsetInterval,
 		Data:  UnsetInterval,
 		Range: UnsetInterval,
+		Zoom:  UnsetInterval,
 		Trans: IdentityTrans,
+
+		Midpoint: math.NaN(),
+		NAColor:  color.Gray{0x7f},
+		NAValue:  math.NaN(),
 	}
 	s.Autoscaling.MinRange = UnsetInterval
 	s.Autoscaling.MaxRange = UnsetInterval
@@ -133,6 +198,86 @@ func (s *Scale) Map(x float64) float64 {
 	return s.Trans.Trans(s.Range, U, x)
 }
 
+// MapDiverging works like Map but honours s's Midpoint: Values below
+// Midpoint are mapped to [0, 0.5], values above to [0.5, 1].
+// If Midpoint is NaN MapDiverging is the same as Map.
+func (s *Scale) MapDiverging(x float64) float64 {
+	mid := s.Midpoint
+	switch {
+	case math.IsNaN(mid):
+		return s.Map(x)
+	case x == mid:
+		return 0.5
+	case x < mid:
+		return s.Trans.Trans(Interval{s.Range.Min, mid}, Interval{0, 0.5}, x)
+	}
+	return s.Trans.Trans(Interval{mid, s.Range.Max}, Interval{0.5, 1}, x)
+}
+
+// Bins returns the edges of the bins of a binned scale s: The first and last
+// edge are s.Range.Min and s.Range.Max, the inner edges are the Breaks
+// (or the major ticks) lying inside the Range.
+func (s *Scale) Bins() []float64 {
+	breaks := s.Breaks
+	if len(breaks) == 0 {
+		for _, tick := range s.ticker().Ticks(s.Limit.Min, s.Limit.Max) {
+			if !tick.IsMinor() {
+				breaks = append(breaks, tick.Value)
+			}
+		}
+	}
+
+	edges := []float64{s.Range.Min}
+	for _, b := range breaks {
+		if b > edges[len(edges)-1] && b < s.Range.Max {
+			edges = append(edges, b)
+		}
+	}
+	return append(edges, s.Range.Max)
+}
+
+// prepareBins computes the bin edges of a binned scale once so that
+// MapBinned needn't recompute them for every value.
+func (s *Scale) prepareBins() {
+	s.bins = nil
+	if s.Binned {
+		s.bins = s.Bins()
+	}
+}
+
+// binEdges returns the bin edges computed by prepareBins or computes
+// them if s has not been prepared.
+func (s *Scale) binEdges() []float64 {
+	if s.bins != nil {
+		return s.bins
+	}
+	return s.Bins()
+}
+
+// ticker returns the Ticker of s or the Ticker of its Trans if unset.
+func (s *Scale) ticker() plot.Ticker {
+	if s.Ticker != nil {
+		return s.Ticker
+	}
+	return s.Trans.Ticker
+}
+
+// MapBinned maps x to the center of its bin where the n bins of s
+// are spaced evenly in [0, 1], i.e. all values in bin k are mapped to
+// (k+0.5)/n. Values outside of the Range are mapped to NaN.
+func (s *Scale) MapBinned(x float64) float64 {
+	if !s.InRange(x) {
+		return math.NaN()
+	}
+	edges := s.binEdges()
+	n := len(edges) - 1
+	k := sort.SearchFloat64s(edges[1:n], x)
+	if k < n-1 && edges[k+1] == x {
+		k++ // Bins are closed on the left.
+	}
+	return (float64(k) + 0.5) / float64(n)
+}
+
 // UpdateData updates s to cover i.
 func (s *Scale) UpdateData(i Interval) {
 	s.Data.Update(i.Min)
@@ -168,6 +313,93 @@ func (s *Scale) InRange(x float64) bool {
 	return x >= s.Range.Min && x <= s.Range.Max
 }
 
+// View returns the interval of an X or Y scale s shown in the panels:
+// The Range of s with the set edges of the Zoom window applied.
+func (s *Scale) View() Interval {
+	v := s.Range
+	if !math.IsNaN(s.Zoom.Min) {
+		v.Min = s.Zoom.Min
+	}
+	if !math.IsNaN(s.Zoom.Max) {
+		v.Max = s.Zoom.Max
+	}
+	if v.Min >= v.Max {
+		return s.Range // ignore degenerate zoom windows
+	}
+	return v
+}
+
+// axisTicks returns the ticks of an X or Y scale s lying in its View.
+// Zoomed scales generate their ticks for the zoom window. The default
+// ticker is replaced by a SpaceTicks for the axis space: An axis of the
+// given length with about number labels in font, stacked vertically or not,
+// whose labels may stick out margin beyond the axis ends.
+func (s *Scale) axisTicks(length, margin vg.Length, number int, font vg.Font, vertical bool) []plot.Tick {
+	view := s.View()
+	min, max := s.Limit.Min, s.Limit.Max
+	if view != s.Range {
+		min, max = view.Min, view.Max
+	}
+	ticker := s.ticker()
+	switch t := ticker.(type) {
+	case DefaultTicks:
+		if s.Ticker == nil {
+			ticker = SpaceTicks{Number: number, Length: length, Margin: margin,
+				Font: font, Vertical: vertical, Format: s.Format}
+		}
+	case SpaceTicks:
+		if t.Number == 0 {
+			t.Number = number
+		}
+		if t.Length == 0 {
+			t.Length = length
+		}
+		if t.Margin == 0 {
+			t.Margin = margin
+		}
+		if t.Font == (vg.Font{}) {
+			t.Font = font
+		}
+		if t.Format == nil {
+			t.Format = s.Format
+		}
+		t.Vertical = vertical
+		ticker = t
+	}
+	var ticks []plot.Tick
+	for _, tick := range ticker.Ticks(min, max) {
+		if tick.Value < view.Min || tick.Value > view.Max {
+			continue
+		}
+		if s.Format != nil && tick.Label != "" {
+			tick.Label = s.Format(tick.Value)
+		}
+		ticks = append(ticks, tick)
+	}
+	return ticks
+}
+
+// ApplyOOB applies the out-of-bounds policy of s to x: Values inside the
+// Range of s and all values of a Keep scale are returned unchanged, a
+// Squish scale clamps x to its Range and a Censor scale reports false.
+// NaN and non-positive values on a Logarithmic scale are always censored
+// (the latter are counted and reported while learning the data range).
+func (s *Scale) ApplyOOB(x float64) (float64, bool) {
+	if math.IsNaN(x) || (s.ScaleType == Logarithmic && x <= 0) {
+		return x, false
+	}
+	if s.InRange(x) {
+		return x, true
+	}
+	switch s.OOB {
+	case Squish:
+		return math.Max(s.Range.Min, math.Min(x, s.Range.Max)), true
+	case Keep:
+		return x, true
+	}
+	return x, false
+}
+
 // String returns a string suitable for debugging s.
 func (s *Scale) String() string {
 	if s == nil {
@@ -232,14 +464,70 @@ func (s *Scale) applyExpansion(min bool) {
 		return
 	}
 
+	// The relative expansion is done in the transformed space, e.g. in
+	// log space for logarithmic scales. So is the absolute expansion of
+	// logarithmic scales which is measured in decades. Reversing
+	// transformations like ReverseTrans map Data.Min to 1.
 	U := Interval{0, 1}
+	low, high := -s.Expand.Releative, 1+s.Expand.Releative
+	if s.Trans.Trans(s.Data, U, s.Data.Min) > s.Trans.Trans(s.Data, U, s.Data.Max) {
+		low, high = high, low
+	}
 	if min {
-		s.Limit.Min = s.Trans.Inverse(U, s.Data, -s.Expand.Releative)
+		s.Limit.Min = s.Trans.Inverse(U, s.Data, low)
 		debug.VVV("Limit.Min", s.Limit.Min, s.Expand)
-		s.Limit.Min -= s.Expand.Absolute
+		if s.ScaleType == Logarithmic {
+			s.Limit.Min /= math.Pow(10, s.Expand.Absolute)
+		} else {
+			s.Limit.Min -= s.Expand.Absolute
+		}
 	} else {
-		s.Limit.Max = s.Trans.Inverse(U, s.Data, 1+s.Expand.Releative)
-		s.Limit.Max += s.Expand.Absolute
+		s.Limit.Max = s.Trans.Inverse(U, s.Data, high)
+		if s.ScaleType == Logarithmic {
+			s.Limit.Max *= math.Pow(10, s.Expand.Absolute)
+		} else {
+			s.Limit.Max += s.Expand.Absolute
+		}
+	}
+}
+
+// degenerateLimit de-degenerates the Limit of s like Interval.Degenerate
+// but keeps the Limit of Logarithmic scales positive.
+func (s *Scale) degenerateLimit() (modified bool) {
+	if s.ScaleType != Logarithmic {
+		return s.Limit.Degenerate()
+	}
+
+	l := &s.Limit
+	valid := func(x float64) bool { return x > 0 && !math.IsInf(x, 0) } // false for NaN
+	switch {
+	case !valid(l.Min) && !valid(l.Max):
+		l.Min, l.Max = 1, 10
+		modified = true
+	case !valid(l.Min):
+		l.Min = l.Max / 10
+		modified = true
+	case !valid(l.Max):
+		l.Max = l.Min * 10
+		modified = true
+	}
+	if l.Min > l.Max {
+		l.Min, l.Max = l.Max, l.Min
+		modified = true
+	}
+	if l.Min == l.Max {
+		l.Min /= 2
+		l.Max *= 2
+		modified = true
+	}
+	return modified
+}
+
+// setupTrans makes Logarithmic scales with a linear or identity Trans use
+// Log10Trans.
+func (s *Scale) setupTrans() {
+	if s.ScaleType == Logarithmic && (s.Trans.Name == LinearTrans.Name || s.Trans.Name == IdentityTrans.Name) {
+		s.Trans = Log10Trans
 	}
 }
 
@@ -326,6 +614,33 @@ func (i *Interval) Degenerate() (modified bool) {
 	return mod
 }
 
+// ----------------------------------------------------------------------------
+// Out-of-bounds policies
+
+// OOB is an out-of-bounds policy: It determines how data values outside
+// of the Range of a scale are handled. Color, Fill and Alpha scales cannot
+// map values outside of their Range and treat Keep like Censor.
+type OOB int
+
+const (
+	Censor OOB = iota // Drop the value or use the scale's NA value.
+	Squish            // Clamp the value to the nearest end of the Range.
+	Keep              // Map the value as is and let the canvas clip.
+)
+
+// String returns the name of o.
+func (o OOB) String() string {
+	switch o {
+	case Censor:
+		return "censor"
+	case Squish:
+		return "squish"
+	case Keep:
+		return "keep"
+	}
+	return fmt.Sprintf("OOB(%d)", int(o))
+}
+
 // ----------------------------------------------------------------------------
 // ScaleType
 
@@ -1,9 +1,16 @@
 package facet
 
 import (
+	"errors"
+	"fmt"
+	"image/color"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
+
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/vg"
 )
 
 var nan = math.NaN()
@@ -33,3 +40,340 @@ func TestIntervalUpdate(t *testing.T) {
 		})
 	}
 }
+
+var mapDivergingTests = []struct {
+	min, max, mid float64
+	x, want       float64
+}{
+	{-10, 30, nan, 10, 0.5},
+	{-10, 30, 0, -10, 0},
+	{-10, 30, 0, -5, 0.25},
+	{-10, 30, 0, 0, 0.5},
+	{-10, 30, 0, 15, 0.75},
+	{-10, 30, 0, 30, 1},
+	{0.5, 1.5, 1, 0.5, 0},
+	{2, 5, 1, 2, 0.625},
+}
+
+func TestMapDiverging(t *testing.T) {
+	for i, tc := range mapDivergingTests {
+		s := NewScale()
+		s.Trans = LinearTrans
+		s.Range = Interval{tc.min, tc.max}
+		s.Midpoint = tc.mid
+		if got := s.MapDiverging(tc.x); !equal64(got, tc.want) {
+			t.Errorf("%d. MapDiverging(%g) = %g, want %g", i, tc.x, got, tc.want)
+		}
+	}
+}
+
+var mapBinnedTests = []struct {
+	x, want float64
+}{
+	{0, 0.125},
+	{9.9, 0.125},
+	{10, 0.375},
+	{49, 0.625},
+	{50, 0.875},
+	{100, 0.875},
+	{101, nan},
+}
+
+func TestMapBinned(t *testing.T) {
+	s := NewScale()
+	s.Trans = LinearTrans
+	s.Limit = Interval{0, 100}
+	s.Range = Interval{0, 100}
+	s.Binned = true
+	s.Breaks = []float64{10, 20, 50}
+	check := func(prepared bool) {
+		for i, tc := range mapBinnedTests {
+			got := s.MapBinned(tc.x)
+			if math.IsNaN(tc.want) && math.IsNaN(got) {
+				continue
+			}
+			if got != tc.want {
+				t.Errorf("%d. prepared=%t MapBinned(%g) = %g, want %g",
+					i, prepared, tc.x, got, tc.want)
+			}
+		}
+	}
+	check(false)
+
+	// Prepared scales map with the bins computed once in prepareBins.
+	s.prepareBins()
+	s.Breaks = nil
+	check(true)
+}
+
+var logTicksTests = []struct {
+	format   string
+	min, max float64
+	labels   string
+	minors   int
+}{
+	{"", 1, 1000, "1 10 100 1000", 24},
+	{"power", 1, 1000, "10⁰ 10¹ 10² 10³", 24},
+	{"si", 0.01, 10000, "10m 100m 1 10 100 1k 10k", 48},
+	{"", 2, 30, "2 5 10 20", 7},
+	{"", -1, 10, "", 0},
+}
+
+func TestLogTicks(t *testing.T) {
+	for i, tc := range logTicksTests {
+		ticks := LogTicks{Format: tc.format}.Ticks(tc.min, tc.max)
+		labels, minors := []string{}, 0
+		for _, tick := range ticks {
+			if tick.Label == "" {
+				minors++
+				continue
+			}
+			labels = append(labels, tick.Label)
+		}
+		if got := strings.Join(labels, " "); got != tc.labels || minors != tc.minors {
+			t.Errorf("%d. LogTicks{%q}.Ticks(%g,%g) = %q with %d minor ticks, want %q with %d",
+				i, tc.format, tc.min, tc.max, got, minors, tc.labels, tc.minors)
+		}
+	}
+}
+
+func TestSpaceTicks(t *testing.T) {
+	font, err := vg.MakeFont("Helvetica", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, tc := range []struct{ min, max float64 }{{0, 1}, {0, 12345}, {-3.5, 7}} {
+		want := fmt.Sprint(DefaultTicks(4).Ticks(tc.min, tc.max))
+		got := fmt.Sprint(SpaceTicks{Number: 4}.Ticks(tc.min, tc.max))
+		if got != want {
+			t.Errorf("[%g,%g] without length: got %s, want %s", tc.min, tc.max, got, want)
+		}
+
+		previous := 0
+		for _, length := range []vg.Length{30, 60, 120, 600} {
+			st := SpaceTicks{Number: 8, Length: length, Font: font}
+			ticks := st.Ticks(tc.min, tc.max)
+			labeled, last, lastWidth := 0, math.Inf(-1), vg.Length(0)
+			for _, tick := range ticks {
+				if tick.Label == "" {
+					continue
+				}
+				labeled++
+				pos := length * vg.Length((tick.Value-tc.min)/(tc.max-tc.min))
+				width := font.Width(tick.Label)
+				if float64(pos-(width+lastWidth)/2) < last {
+					t.Errorf("[%g,%g] length %.0f: label %q overlaps",
+						tc.min, tc.max, length, tick.Label)
+				}
+				last, lastWidth = float64(pos), width
+			}
+			if labeled < previous {
+				t.Errorf("[%g,%g] length %.0f: %d labels, fewer than %d on shorter axis",
+					tc.min, tc.max, length, labeled, previous)
+			}
+			previous = labeled
+		}
+	}
+
+	// If not even two labels fit at most one is kept.
+	for _, length := range []vg.Length{5, 20, 40} {
+		st := SpaceTicks{Number: 8, Length: length, Font: font}
+		labeled, major := 0, 0
+		for _, tick := range st.Ticks(123456, 987654) {
+			if tick.Label != "" {
+				labeled++
+				if width := font.Width(tick.Label); width > length {
+					t.Errorf("length %.0f: label %q too long", length, tick.Label)
+				}
+			}
+			if math.Mod(tick.Value, 100000) == 0 {
+				major++
+			}
+		}
+		if labeled > 1 || major < 2 {
+			t.Errorf("length %.0f: %d labels and %d major ticks, want at most 1 and 2 or more",
+				length, labeled, major)
+		}
+	}
+}
+
+var logLimitTests = []struct {
+	limit, want Interval
+}{
+	{Interval{2, 50}, Interval{2, 50}},
+	{Interval{nan, nan}, Interval{1, 10}},
+	{Interval{-3, 50}, Interval{5, 50}},
+	{Interval{4, 0}, Interval{4, 40}},
+	{Interval{8, 8}, Interval{4, 16}},
+}
+
+func TestLogarithmicLimit(t *testing.T) {
+	for i, tc := range logLimitTests {
+		s := NewScale()
+		s.ScaleType = Logarithmic
+		s.Limit = tc.limit
+		s.degenerateLimit()
+		if s.Limit != tc.want {
+			t.Errorf("%d. %v: got %v, want %v", i, tc.limit, s.Limit, tc.want)
+		}
+	}
+
+	// Absolute expansion is measured in decades.
+	s := NewScale()
+	s.ScaleType = Logarithmic
+	s.setupTrans()
+	s.Data = Interval{10, 1000}
+	s.Expand.Absolute = 1
+	s.applyExpansion(true)
+	s.applyExpansion(false)
+	if !equal64(s.Limit.Min, 1) || !equal64(s.Limit.Max, 10000) {
+		t.Errorf("expanded limit = %v, want [1, 10000]", s.Limit)
+	}
+}
+
+func TestReversedExpansion(t *testing.T) {
+	s := NewScale()
+	s.Trans = ReverseTrans
+	s.Data = Interval{10, 20}
+	s.Expand.Releative = 0.1
+	s.applyExpansion(true)
+	s.applyExpansion(false)
+	if !equal64(s.Limit.Min, 9) || !equal64(s.Limit.Max, 21) {
+		t.Errorf("expanded limit = %v, want [9, 21]", s.Limit)
+	}
+}
+
+var applyOOBTests = []struct {
+	oob  OOB
+	x    float64
+	want float64
+	ok   bool
+}{
+	{Censor, 5, 5, true},
+	{Censor, 12, 12, false},
+	{Squish, 12, 10, true},
+	{Squish, -3, 0, true},
+	{Keep, 12, 12, true},
+	{Keep, nan, nan, false},
+}
+
+func TestApplyOOB(t *testing.T) {
+	for i, tc := range applyOOBTests {
+		s := NewScale()
+		s.Range = Interval{0, 10}
+		s.OOB = tc.oob
+		got, ok := s.ApplyOOB(tc.x)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("%d. %s ApplyOOB(%g) = %g, %t, want %g, %t",
+				i, tc.oob, tc.x, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	// Non-positive values cannot be shown on logarithmic scales.
+	for _, oob := range []OOB{Censor, Squish, Keep} {
+		s := NewScale()
+		s.ScaleType = Logarithmic
+		s.Range = Interval{1, 100}
+		s.OOB = oob
+		for _, x := range []float64{0, -5} {
+			if got, ok := s.ApplyOOB(x); ok {
+				t.Errorf("log %s ApplyOOB(%g) = %g, not censored", oob, x, got)
+			}
+		}
+		if got, ok := s.ApplyOOB(200); ok != (oob != Censor) {
+			t.Errorf("log %s ApplyOOB(200) = %g, %t", oob, got, ok)
+		}
+	}
+}
+
+// failingMap is a ColorMap whose At always fails.
+type failingMap struct {
+	palette.ColorMap
+}
+
+func (failingMap) At(float64) (color.Color, error) {
+	return nil, errors.New("failingMap")
+}
+
+func TestOOBString(t *testing.T) {
+	for _, tc := range []struct {
+		oob  OOB
+		want string
+	}{
+		{Censor, "censor"},
+		{Squish, "squish"},
+		{Keep, "keep"},
+		{OOB(5), "OOB(5)"},
+	} {
+		if got := fmt.Sprintf("%v", tc.oob); got != tc.want {
+			t.Errorf("%d: got %q, want %q", int(tc.oob), got, tc.want)
+		}
+	}
+}
+
+func TestMapNA(t *testing.T) {
+	p := NewSimplePlot()
+	for _, s := range []int{ColorScale, SizeScale, AlphaScale} {
+		p.Scales[s].Range = Interval{0, 10}
+	}
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	p.Scales[ColorScale].NAColor = red
+	if got := p.MapColor(12, false); got != red {
+		t.Errorf("censored color = %v, want %v", got, red)
+	}
+	p.Scales[ColorScale].OOB = Squish
+	if got, want := p.MapColor(12, false), p.MapColor(10, false); got != want {
+		t.Errorf("squished color = %v, want %v", got, want)
+	}
+	p.ColorMap = failingMap{p.ColorMap}
+	if got := p.MapColor(5, false); got != red {
+		t.Errorf("color of failing color map = %v, want %v", got, red)
+	}
+
+	if got := p.MapSize(nan); got != 0 {
+		t.Errorf("NaN size = %v, want 0", got)
+	}
+	p.Scales[SizeScale].NAValue = 1
+	if got := p.MapSize(-1); got != 1 {
+		t.Errorf("censored size = %v, want 1", got)
+	}
+
+	if got := p.MapAlpha(12); !math.IsNaN(got) {
+		t.Errorf("censored alpha = %v, want NaN", got)
+	}
+	p.Scales[AlphaScale].OOB = Squish
+	if got := p.MapAlpha(12); got != 1 {
+		t.Errorf("squished alpha = %v, want 1", got)
+	}
+}
+
+func TestZoom(t *testing.T) {
+	s := NewScale()
+	s.Limit, s.Range = Interval{0, 100}, Interval{0, 100}
+	if got := s.View(); got != s.Range {
+		t.Errorf("unzoomed view = %v, want %v", got, s.Range)
+	}
+
+	s.Zoom = Interval{90, nan}
+	if got, want := s.View(), (Interval{90, 100}); got != want {
+		t.Errorf("view = %v, want %v", got, want)
+	}
+	ticks := s.axisTicks(0, 0, 4, vg.Font{}, false)
+	if len(ticks) == 0 {
+		t.Fatalf("no ticks in zoom window")
+	}
+	for _, tick := range ticks {
+		if tick.Value < 90 || tick.Value > 100 {
+			t.Errorf("tick %g outside of zoom window", tick.Value)
+		}
+	}
+	if x, ok := s.ApplyOOB(50); !ok || x != 50 {
+		t.Errorf("zoom censored data value 50")
+	}
+
+	s.Zoom = Interval{50, 40}
+	if got := s.View(); got != s.Range {
+		t.Errorf("degenerate zoom: view = %v, want %v", got, s.Range)
+	}
+}
@@ -3,6 +3,7 @@ package facet
 import (
 	"image/color"
 	"math"
+	"reflect"
 
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
@@ -20,6 +21,10 @@ type Style struct {
 		Background color.Color
 		PadX       vg.Length
 		PadY       vg.Length
+
+		// Border is the style of the frame drawn around each panel
+		// on top of the data.
+		Border draw.LineStyle
 	}
 	HStrip struct {
 		Background color.Color
@@ -38,19 +43,38 @@ type Style struct {
 	}
 
 	XAxis struct {
+		// Position of the X axis: "bottom" (default) or "top".
+		// Plot.Draw fails for other values.
+		Position string
+
+		// Repeat the axis below (or above) every row of panels
+		// instead of only the outermost one.
+		Repeat bool
+
 		Title       draw.TextStyle
 		TitleHeight vg.Length
-		Line        draw.LineStyle
-		Expand      struct {
+
+		// Line is the style of the axis line along the panel border.
+		Line   draw.LineStyle
+		Expand struct {
 			Absolute  float64
 			Releative float64
 		}
+		// The Align field of MajorTick and MinorTick determines the
+		// tick direction: TicksOutside, TicksCrossing or TicksInside.
 		MajorTick struct {
 			Number int
 			draw.LineStyle
 			Length vg.Length
 			Align  draw.YAlignment
-			Label  draw.TextStyle
+
+			// Label is the style of the tick labels. Labels with
+			// a non-zero Rotation are anchored at their end next
+			// to the tick, ignoring XAlign and YAlign.
+			Label draw.TextStyle
+
+			// Stagger places every other label on a second row.
+			Stagger bool
 		}
 		MinorTick struct {
 			draw.LineStyle
@@ -60,13 +84,25 @@ type Style struct {
 	}
 
 	YAxis struct {
+		// Position of the Y axis: "left" (default) or "right".
+		// Plot.Draw fails for other values.
+		Position string
+
+		// Repeat the axis left (or right) of every column of panels
+		// instead of only the outermost one.
+		Repeat bool
+
 		Title      draw.TextStyle
 		TitleWidth vg.Length
-		Line       draw.LineStyle
-		Expand     struct {
+
+		// Line is the style of the axis line along the panel border.
+		Line   draw.LineStyle
+		Expand struct {
 			Absolute  float64
 			Releative float64
 		}
+		// The Align field of MajorTick and MinorTick determines the
+		// tick direction: TicksOutside, TicksCrossing or TicksInside.
 		MajorTick struct {
 			Number int
 			draw.LineStyle
@@ -82,18 +118,61 @@ type Style struct {
 	}
 
 	Legend struct {
-		Position string // left
-		Title    draw.TextStyle
-		Label    draw.TextStyle
+		// Position of the legend: "right" (default), "left", "top",
+		// "bottom", "inside" or "none". Plot.Draw fails for other
+		// values.
+		Position string
+
+		// Inside is the position of the legend in normalised [0,1]
+		// coordinates of the area covered by all panels if Position
+		// is "inside": {0,0} is the bottom left and {1,1} the top right
+		// corner. The same point of the legend is placed there.
+		Inside struct {
+			X, Y float64
+		}
+
+		Title draw.TextStyle
+		Label draw.TextStyle
+
+		// Order lists scales in the order their guides are stacked.
+		// Guides of scales not listed follow in the default order.
+		Order []int
 
 		Discrete struct {
 			Size vg.Length
 			Pad  vg.Length
+
+			// Background is the color of the boxes the keys are
+			// drawn in.
+			Background color.Color
+
+			// Columns and Rows determine the number of columns or
+			// rows the keys are laid out in. If both are zero the keys
+			// are stacked in one column (or are laid out in one row
+			// which wraps for legends on top or bottom).
+			Columns, Rows int
+
+			// ByRow fills the keys row by row instead of column by
+			// column.
+			ByRow bool
+
+			// Reverse the order of the keys.
+			Reverse bool
 		}
 		Continuous struct {
 			Size   vg.Length
 			Length vg.Length
-			Tick   struct {
+
+			// Horizontal draws the color bar horizontally. Color bars
+			// of legends on top or bottom are always horizontal.
+			Horizontal bool
+
+			// LabelPosition is the side of the color bar the labels
+			// are drawn on: "right" (default) or "left" for vertical
+			// bars, "bottom" (default) or "top" for horizontal ones.
+			LabelPosition string
+
+			Tick struct {
 				draw.LineStyle
 				Length vg.Length
 				Align  draw.XAlignment
@@ -112,24 +191,17 @@ type Style struct {
 
 // DefaultFacetStyle returns a FacetStyle which mimics the appearance of ggplot2.
 // The baseFontSize is the font size for axis titles and strip labels, the title
-// is a bit bigger, tick labels a bit smaller.
+// is a bit bigger, tick labels a bit smaller. All text is drawn in the bold
+// variant of DefaultFontFamily (or Helvetica if it cannot be loaded) with a
+// FallbackTextHandler, i.e. as is. Markup can be enabled with MarkupTheme.
 func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	scale := func(x vg.Length, f float64) vg.Length {
 		return vg.Length(math.Round(f * float64(x)))
 	}
 
-	titleFont, err := vg.MakeFont("Helvetica-Bold", scale(baseFontSize, 1.2))
-	if err != nil {
-		panic(err)
-	}
-	baseFont, err := vg.MakeFont("Helvetica-Bold", baseFontSize)
-	if err != nil {
-		panic(err)
-	}
-	tickFont, err := vg.MakeFont("Helvetica-Bold", scale(baseFontSize, 1/1.2))
-	if err != nil {
-		panic(err)
-	}
+	titleFont := defaultFont(Bold, scale(baseFontSize, 1.2))
+	baseFont := defaultFont(Bold, baseFontSize)
+	tickFont := defaultFont(Bold, scale(baseFontSize, 1/1.2))
 
 	fs := Style{}
 	fs.Background = color.Transparent
@@ -163,6 +235,7 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	fs.Grid.Minor.Width = vg.Length(0.5)
 
 	// X-Axis
+	fs.XAxis.Position = "bottom"
 	fs.XAxis.Expand.Releative = 0.05
 	fs.XAxis.Title.Color = color.Black
 	fs.XAxis.Title.Font = baseFont
@@ -177,12 +250,12 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	fs.XAxis.MajorTick.Color = color.Gray16{0x1111}
 	fs.XAxis.MajorTick.Width = vg.Length(1)
 	fs.XAxis.MajorTick.Length = vg.Length(5)
-	fs.XAxis.MajorTick.Align = 0
+	fs.XAxis.MajorTick.Align = TicksOutside
 
 	fs.XAxis.MinorTick.Color = nil
 	fs.XAxis.MinorTick.Width = vg.Length(0)
 	fs.XAxis.MinorTick.Length = vg.Length(0)
-	fs.XAxis.MinorTick.Align = 0
+	fs.XAxis.MinorTick.Align = TicksOutside
 
 	fs.XAxis.MajorTick.Label.Color = color.Black
 	fs.XAxis.MajorTick.Label.Font = tickFont
@@ -190,6 +263,7 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	fs.XAxis.MajorTick.Label.YAlign = draw.YTop
 
 	// Y-Axis
+	fs.YAxis.Position = "left"
 	fs.YAxis.Expand.Releative = 0.05
 	fs.YAxis.Title.Color = color.Black
 	fs.YAxis.Title.Font = baseFont
@@ -205,7 +279,7 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	fs.YAxis.MajorTick.Color = color.Gray16{0x1111}
 	fs.YAxis.MajorTick.Width = vg.Length(1)
 	fs.YAxis.MajorTick.Length = vg.Length(5)
-	fs.YAxis.MajorTick.Align = 0
+	fs.YAxis.MajorTick.Align = TicksOutside
 	fs.YAxis.MajorTick.Label.Color = color.Black
 	fs.YAxis.MajorTick.Label.Font = tickFont
 	fs.YAxis.MajorTick.Label.XAlign = draw.XRight
@@ -215,9 +289,10 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	fs.YAxis.MinorTick.Color = nil
 	fs.YAxis.MinorTick.Width = 0
 	fs.YAxis.MinorTick.Length = 0
-	fs.YAxis.MinorTick.Align = 0
+	fs.YAxis.MinorTick.Align = TicksOutside
 
 	fs.Legend.Position = "right"
+	fs.Legend.Inside.X, fs.Legend.Inside.Y = 1, 1
 
 	fs.Legend.Label.Color = color.Black
 	fs.Legend.Label.Font = tickFont
@@ -230,6 +305,7 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 
 	fs.Legend.Discrete.Size = vg.Length(20)
 	fs.Legend.Discrete.Pad = vg.Length(4)
+	fs.Legend.Discrete.Background = color.Gray{0xee}
 
 	fs.Legend.Continuous.Size = vg.Length(20)
 	fs.Legend.Continuous.Length = vg.Length(150)
@@ -243,5 +319,9 @@ func DefaultFacetStyle(baseFontSize vg.Length) Style {
 	fs.GeomDefault.FillColor = color.NRGBA{0x30, 0x30, 0x30, 0xff}
 	fs.GeomDefault.LineWidth = vg.Length(1)
 	fs.GeomDefault.Size = vg.Length(3)
+	for _, sty := range textStyles(reflect.ValueOf(&fs).Elem()) {
+		sty.Handler = FallbackTextHandler{}
+	}
+
 	return fs
 }
@@ -0,0 +1,342 @@
+package facet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// JSON encoding of Styles
+
+var (
+	colorType       = reflect.TypeOf((*color.Color)(nil)).Elem()
+	lengthType      = reflect.TypeOf(vg.Length(0))
+	fontType        = reflect.TypeOf(vg.Font{})
+	textHandlerType = reflect.TypeOf((*draw.TextHandler)(nil)).Elem()
+)
+
+// MarshalJSON encodes s as JSON: Colors are written as hex strings like
+// "#ebebeb" ("none" for no color), lengths with unit like "12pt" and fonts
+// as objects like {"Family": "helvetica", "Variant": "bold", "Size": "12pt"}
+// (fonts not belonging to a registered FontFamily by their name only).
+// Text handlers are not encoded.
+func (s Style) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encodeStyleValue(reflect.ValueOf(s)))
+}
+
+// UnmarshalJSON decodes data into s. Fields missing in data keep their
+// current value, so decoding into a DefaultFacetStyle merges data over the
+// defaults:
+//     s := DefaultFacetStyle(12)
+//     err := json.Unmarshal(data, &s)
+// Colors may be given as hex strings "#rgb", "#rrggbb" and "#rrggbbaa",
+// as CSS color names like "steelblue" or as "none" and "transparent".
+// Lengths may be given as numbers (in points) or as strings with one of
+// the units "pt", "mm", "cm" or "in". Fonts are made with MakeFont: The
+// Family is a registered font family like "Times" or the name of a single
+// font like "Times-Roman", the Variant one of "regular", "bold", "italic"
+// or "bolditalic". Fonts may omit Family, Variant or Size. Unknown fields
+// are reported as an error.
+func (s *Style) UnmarshalJSON(data []byte) error {
+	return decodeStyleValue(reflect.ValueOf(s).Elem(), data, "")
+}
+
+// jsonObject is a JSON object which keeps the order of its fields.
+type jsonObject []jsonField
+
+type jsonField struct {
+	Name  string
+	Value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// encodeStyleValue returns the JSON representation of v, a (part of a)
+// Style.
+func encodeStyleValue(v reflect.Value) interface{} {
+	switch v.Type() {
+	case colorType:
+		if v.IsNil() {
+			return "none"
+		}
+		return colorString(v.Interface().(color.Color))
+	case lengthType:
+		return lengthString(vg.Length(v.Float()))
+	case fontType:
+		font := v.Interface().(vg.Font)
+		family, ok := fontFamilyName(font.Name())
+		if !ok {
+			return jsonObject{
+				{"Family", font.Name()},
+				{"Size", lengthString(font.Size)},
+			}
+		}
+		return jsonObject{
+			{"Family", family},
+			{"Variant", fontVariant(font.Name()).String()},
+			{"Size", lengthString(font.Size)},
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		obj := jsonObject{}
+		for _, f := range styleFields(v) {
+			obj = append(obj, jsonField{f.name, encodeStyleValue(f.value)})
+		}
+		return obj
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = encodeStyleValue(v.Index(i))
+		}
+		return list
+	}
+	return v.Interface()
+}
+
+// decodeStyleValue decodes data into v, a (part of a) Style found under
+// the given path.
+func decodeStyleValue(v reflect.Value, data []byte, path string) error {
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("facet: style field %s: %s", path, fmt.Sprintf(format, args...))
+	}
+
+	switch v.Type() {
+	case colorType:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return errorf("%s", err)
+		}
+		c, err := parseColor(s)
+		if err != nil {
+			return errorf("%s", err)
+		}
+		if c == nil {
+			v.Set(reflect.Zero(colorType))
+		} else {
+			v.Set(reflect.ValueOf(c))
+		}
+		return nil
+	case lengthType:
+		l, err := parseLengthJSON(data)
+		if err != nil {
+			return errorf("%s", err)
+		}
+		v.SetFloat(float64(l))
+		return nil
+	case fontType:
+		font := v.Interface().(vg.Font)
+		var spec struct {
+			Family  string
+			Variant string
+			Size    json.RawMessage
+		}
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return errorf("%s", err)
+		}
+		family, variant, size := font.Name(), fontVariant(font.Name()), font.Size
+		if name, ok := fontFamilyName(family); ok {
+			family = name
+		}
+		if spec.Family != "" {
+			family = spec.Family
+		}
+		if spec.Variant != "" {
+			var err error
+			if variant, err = parseFontVariant(spec.Variant); err != nil {
+				return errorf("%s", err)
+			}
+		}
+		if spec.Size != nil {
+			var err error
+			if size, err = parseLengthJSON(spec.Size); err != nil {
+				return errorf("%s", err)
+			}
+		}
+		if family == "" {
+			v.Set(reflect.ValueOf(vg.Font{Size: size}))
+			return nil
+		}
+		font, err := MakeFont(family, variant, size)
+		if err != nil {
+			return errorf("%s", err)
+		}
+		v.Set(reflect.ValueOf(font))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return errorf("%s", err)
+		}
+		fields := styleFields(v)
+	outer:
+		for name, raw := range obj {
+			for _, f := range fields {
+				if strings.EqualFold(f.name, name) {
+					if err := decodeStyleValue(f.value, raw, joinPath(path, f.name)); err != nil {
+						return err
+					}
+					continue outer
+				}
+			}
+			return fmt.Errorf("facet: unknown style field %s", joinPath(path, name))
+		}
+		return nil
+	case reflect.Slice:
+		var list []json.RawMessage
+		if err := json.Unmarshal(data, &list); err != nil {
+			return errorf("%s", err)
+		}
+		if list == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, raw := range list {
+			if err := decodeStyleValue(slice.Index(i), raw, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	if err := json.Unmarshal(data, v.Addr().Interface()); err != nil {
+		return errorf("%s", err)
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// styleField is a named field of a struct in a Style.
+type styleField struct {
+	name  string
+	value reflect.Value
+}
+
+// styleFields returns the exported fields of the struct v. Fields of
+// embedded structs are promoted like in encoding/json; text handlers are
+// skipped.
+func styleFields(v reflect.Value) []styleField {
+	var fields []styleField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		switch {
+		case sf.PkgPath != "", sf.Type == textHandlerType:
+			continue
+		case sf.Anonymous && sf.Type.Kind() == reflect.Struct:
+			fields = append(fields, styleFields(v.Field(i))...)
+		default:
+			fields = append(fields, styleField{sf.Name, v.Field(i)})
+		}
+	}
+	return fields
+}
+
+// colorString formats c as "#rrggbb" or "#rrggbbaa" if c is not opaque.
+func colorString(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0 {
+		return "transparent"
+	}
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// parseColor parses a hex color "#rgb", "#rrggbb" or "#rrggbbaa" or a
+// CSS color name. The empty string and "none" result in a nil color.
+func parseColor(s string) (color.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "none":
+		return nil, nil
+	case "transparent":
+		return color.Transparent, nil
+	}
+	if !strings.HasPrefix(s, "#") {
+		c, ok := colornames.Map[s]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", s)
+		}
+		return c, nil
+	}
+
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return nil, fmt.Errorf("malformed color %q", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// lengthString formats l in points, e.g. "12pt".
+func lengthString(l vg.Length) string {
+	return strconv.FormatFloat(l.Points(), 'g', 6, 64) + "pt"
+}
+
+// parseLengthJSON parses a length given as a number of points or as a
+// string with unit.
+func parseLengthJSON(data []byte) (vg.Length, error) {
+	var x float64
+	if err := json.Unmarshal(data, &x); err == nil {
+		return vg.Length(x), nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, fmt.Errorf("malformed length %s", data)
+	}
+	l, err := vg.ParseLength(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("malformed length %q", s)
+	}
+	return l, nil
+}
@@ -0,0 +1,141 @@
+package facet
+
+import (
+	"encoding/json"
+	"image/color"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/plot/vg"
+)
+
+func TestStyleJSONRoundTrip(t *testing.T) {
+	for _, theme := range []Theme{GreyTheme, BWTheme, PrintTheme} {
+		s := NewStyle(12, theme)
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		// Decoding into a different style must reproduce s.
+		other := NewStyle(9, DarkTheme)
+		if err := json.Unmarshal(data, &other); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		again, _ := json.Marshal(other)
+		if string(again) != string(data) {
+			t.Errorf("round trip differs:\n%s\n%s", data, again)
+		}
+	}
+}
+
+func TestStyleJSONMerge(t *testing.T) {
+	data := `{
+	    "Panel": {"Background": "white", "Border": {"Color": "#333", "Width": "1mm"}},
+	    "Grid": {"Minor": {"Color": "none"}},
+	    "XAxis": {"MajorTick": {"Label": {"Font": {"Size": "14pt"}}, "Length": 3}},
+	    "Legend": {"position": "bottom"}
+	}`
+	s := DefaultFacetStyle(12)
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	def := DefaultFacetStyle(12)
+
+	if got := colorString(s.Panel.Background); got != "#ffffff" {
+		t.Errorf("Panel.Background = %s", got)
+	}
+	if got := colorString(s.Panel.Border.Color); got != "#333333" {
+		t.Errorf("Panel.Border.Color = %s", got)
+	}
+	if s.Panel.Border.Width != vg.Millimeter {
+		t.Errorf("Panel.Border.Width = %v", s.Panel.Border.Width)
+	}
+	if s.Grid.Minor.Color != nil || s.Grid.Minor.Width != def.Grid.Minor.Width {
+		t.Errorf("Grid.Minor = %v", s.Grid.Minor)
+	}
+	label := s.XAxis.MajorTick.Label.Font
+	if label.Size != 14 || label.Name() != def.XAxis.MajorTick.Label.Font.Name() {
+		t.Errorf("font = %s %v", label.Name(), label.Size)
+	}
+	if s.XAxis.MajorTick.Length != 3 || s.XAxis.MajorTick.Width != def.XAxis.MajorTick.Width {
+		t.Errorf("XAxis.MajorTick = %v %v", s.XAxis.MajorTick.Length, s.XAxis.MajorTick.Width)
+	}
+	if s.Legend.Position != "bottom" {
+		t.Errorf("Legend.Position = %q", s.Legend.Position)
+	}
+	if s.Panel.PadX != def.Panel.PadX || s.Title.Font.Size != def.Title.Font.Size {
+		t.Errorf("missing fields not kept")
+	}
+}
+
+func TestStyleJSONFonts(t *testing.T) {
+	data, err := json.Marshal(DefaultFacetStyle(12))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if want := `"Font":{"Family":"helvetica","Variant":"bold","Size":"14pt"}`; !strings.Contains(string(data), want) {
+		t.Errorf("title font not encoded as %s", want)
+	}
+
+	for _, tc := range []struct {
+		font string
+		want string
+		size vg.Length
+	}{
+		{`{"Family": "Times"}`, "Times-Bold", 14},
+		{`{"Family": "courier", "Variant": "Italic"}`, "Courier-Oblique", 14},
+		{`{"Variant": "regular", "Size": 10}`, "Helvetica", 10},
+		{`{"Family": "Times-Roman"}`, "Times-Roman", 14},
+	} {
+		s := DefaultFacetStyle(12)
+		if err := json.Unmarshal([]byte(`{"Title": {"Font": `+tc.font+`}}`), &s); err != nil {
+			t.Errorf("%s: unexpected error %s", tc.font, err)
+			continue
+		}
+		if got := s.Title.Font; got.Name() != tc.want || got.Size != tc.size {
+			t.Errorf("%s: got %s %v, want %s %v", tc.font, got.Name(), got.Size, tc.want, tc.size)
+		}
+	}
+}
+
+func TestStyleJSONErrors(t *testing.T) {
+	for _, tc := range []struct{ data, err string }{
+		{`{"Panel": {"Colour": "red"}}`, "unknown style field Panel.Colour"},
+		{`{"Panel": {"Background": "reddish"}}`, "Panel.Background: unknown color"},
+		{`{"Panel": {"Background": "#12345"}}`, "Panel.Background: malformed color"},
+		{`{"Panel": {"PadX": "3furlong"}}`, "Panel.PadX: malformed length"},
+		{`{"Title": {"Font": {"Family": "NoSuchFont"}}}`, "Title.Font"},
+		{`{"Title": {"Font": {"Variant": "heavy"}}}`, "Title.Font: facet: unknown font variant"},
+	} {
+		s := DefaultFacetStyle(12)
+		err := json.Unmarshal([]byte(tc.data), &s)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v, want %q", tc.data, err, tc.err)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want color.Color
+	}{
+		{"none", nil},
+		{"", nil},
+		{"transparent", color.Transparent},
+		{"#fff", color.NRGBA{0xff, 0xff, 0xff, 0xff}},
+		{"#12ab34", color.NRGBA{0x12, 0xab, 0x34, 0xff}},
+		{"#12AB3480", color.NRGBA{0x12, 0xab, 0x34, 0x80}},
+		{"SteelBlue", color.RGBA{0x46, 0x82, 0xb4, 0xff}},
+	} {
+		got, err := parseColor(tc.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: got %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
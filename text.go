@@ -0,0 +1,365 @@
+package facet
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// Rich Text
+
+// MarkupTextHandler draws text with a small markup language:
+//     x^2, x^{n+1}    superscripts
+//     CO_2, x_{i,j}   subscripts
+//     \b{bold}        the bold variant of the font
+//     \i{italic}      the italic variant of the font
+//     \alpha, \Omega  Greek letters by their name
+//     \^ \_ \{ \} \\  the literal characters
+// Super- and subscripts can be nested, e.g. "e^{x_i}", and line breaks are
+// given as "\n". Bold and italic spans use the font's FontFamily, see
+// RegisterFontFamily. Like FallbackTextHandler it uses one of the
+// FallbackFonts for characters missing in the font. Text without markup
+// is drawn exactly like with FallbackTextHandler.
+type MarkupTextHandler struct{}
+
+var _ draw.TextHandler = MarkupTextHandler{}
+
+// Box implements draw.TextHandler.
+func (MarkupTextHandler) Box(txt string, font vg.Font) (width, height, depth vg.Length) {
+	if !hasMarkup(txt) {
+		return FallbackTextHandler{}.Box(txt, font)
+	}
+	tl := layoutMarkup(txt, font)
+	return tl.width, tl.height, tl.depth
+}
+
+// Draw implements draw.TextHandler.
+func (MarkupTextHandler) Draw(c *draw.Canvas, txt string, sty draw.TextStyle, pt vg.Point) {
+	if !hasMarkup(txt) {
+		FallbackTextHandler{}.Draw(c, txt, sty, pt)
+		return
+	}
+	tl := layoutMarkup(txt, sty.Font)
+	if len(tl.lines) == 0 {
+		return
+	}
+
+	c.SetColor(sty.Color)
+	if sty.Rotation != 0 {
+		c.Push()
+		c.Rotate(sty.Rotation)
+	}
+
+	// Like draw.PlainTextHandler work in the rotated coordinate system
+	// and place the first baseline the same way.
+	sin, cos := math.Sincos(sty.Rotation)
+	pt.X, pt.Y = pt.Y*vg.Length(sin)+pt.X*vg.Length(cos), pt.Y*vg.Length(cos)-pt.X*vg.Length(sin)
+	ht := tl.height + tl.depth
+	y := pt.Y + ht*vg.Length(sty.YAlign) + ht - tl.lines[0].ascent +
+		sty.Font.Size - sty.Font.Extents().Height
+	for i, line := range tl.lines {
+		if i > 0 {
+			y -= tl.lines[i-1].advance
+		}
+		x := pt.X + vg.Length(sty.XAlign)*line.width
+		for _, span := range line.spans {
+			c.FillString(span.font, vg.Point{X: x + span.x, Y: y + span.rise}, span.text)
+		}
+	}
+
+	if sty.Rotation != 0 {
+		c.Pop()
+	}
+}
+
+// hasMarkup reports whether txt contains characters with a special meaning
+// in MarkupTextHandler.
+func hasMarkup(txt string) bool {
+	return strings.ContainsAny(txt, `^_\{}`)
+}
+
+// textSpan is a run of text drawn in the same font at the same baseline.
+type textSpan struct {
+	text    string
+	variant FontVariant // added to the variant of the base font
+	scale   float64     // of the font size
+	rise    float64     // of the baseline, in units of the font size
+}
+
+// markupState is the state of the markup parser in a group.
+type markupState struct {
+	variant     FontVariant
+	scale, rise float64
+}
+
+// script returns the state of a superscript (up) or subscript in st.
+func (st markupState) script(up bool) markupState {
+	if up {
+		st.rise += 0.4 * st.scale
+	} else {
+		st.rise -= 0.2 * st.scale
+	}
+	st.scale *= 0.7
+	return st
+}
+
+// markupParser splits markup into lines of text spans.
+type markupParser struct {
+	src   []rune
+	pos   int
+	lines [][]textSpan
+	buf   []rune
+}
+
+// parseMarkup parses txt as described in MarkupTextHandler.
+func parseMarkup(txt string) [][]textSpan {
+	p := &markupParser{src: []rune(strings.TrimRight(txt, "\n"))}
+	p.lines = [][]textSpan{nil}
+	p.group(markupState{scale: 1}, false)
+	return p.lines
+}
+
+// flush adds the pending text as a span in state st.
+func (p *markupParser) flush(st markupState) {
+	if len(p.buf) == 0 {
+		return
+	}
+	n := len(p.lines) - 1
+	p.lines[n] = append(p.lines[n], textSpan{string(p.buf), st.variant, st.scale, st.rise})
+	p.buf = p.buf[:0]
+}
+
+// group parses text in state st until the closing brace (if inGroup) or
+// the end of the text.
+func (p *markupParser) group(st markupState, inGroup bool) {
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '\n':
+			p.flush(st)
+			p.lines = append(p.lines, nil)
+			p.pos++
+		case r == '}' && inGroup:
+			p.pos++
+			p.flush(st)
+			return
+		case r == '{':
+			p.flush(st)
+			p.pos++
+			p.group(st, true)
+		case (r == '^' || r == '_') && p.pos+1 < len(p.src):
+			p.flush(st)
+			p.pos++
+			p.atom(st.script(r == '^'))
+		case r == '\\':
+			p.command(st)
+		default:
+			p.buf = append(p.buf, r)
+			p.pos++
+		}
+	}
+	p.flush(st)
+}
+
+// atom parses the argument of a super- or subscript: A group, a command or
+// a single character.
+func (p *markupParser) atom(st markupState) {
+	switch r := p.src[p.pos]; r {
+	case '{':
+		p.pos++
+		p.group(st, true)
+	case '\\':
+		p.command(st)
+		p.flush(st)
+	default:
+		p.buf = append(p.buf, r)
+		p.pos++
+		p.flush(st)
+	}
+}
+
+// command parses an escaped character, a Greek letter or a font variant
+// command starting at the backslash. Unknown commands are kept literally.
+func (p *markupParser) command(st markupState) {
+	p.pos++ // the backslash
+	if p.pos == len(p.src) {
+		p.buf = append(p.buf, '\\')
+		return
+	}
+	if r := p.src[p.pos]; strings.ContainsRune(`^_{}\`, r) {
+		p.buf = append(p.buf, r)
+		p.pos++
+		return
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && p.pos-start < 10 && isASCIILetter(p.src[p.pos]) {
+		p.pos++
+	}
+	name := string(p.src[start:p.pos])
+	if r, ok := greekLetters[name]; ok {
+		p.buf = append(p.buf, r)
+		return
+	}
+	if (name == "b" || name == "i") && p.pos < len(p.src) && p.src[p.pos] == '{' {
+		p.flush(st)
+		if name == "b" {
+			st.variant |= Bold
+		} else {
+			st.variant |= Italic
+		}
+		p.pos++
+		p.group(st, true)
+		return
+	}
+	p.buf = append(p.buf, '\\')
+	p.buf = append(p.buf, []rune(name)...)
+}
+
+func isASCIILetter(r rune) bool {
+	return r <= unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+// greekLetters maps the names of the Greek letters to their runes.
+var greekLetters = map[string]rune{}
+
+func init() {
+	names := []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta",
+		"eta", "theta", "iota", "kappa", "lambda", "mu", "nu", "xi",
+		"omicron", "pi", "rho", "", "sigma", "tau", "upsilon", "phi", "chi",
+		"psi", "omega"}
+	for i, name := range names {
+		if name == "" {
+			continue // final sigma and the reserved capital
+		}
+		greekLetters[name] = 'α' + rune(i)
+		greekLetters[strings.ToUpper(name[:1])+name[1:]] = 'Α' + rune(i)
+	}
+}
+
+// textLayout is the result of laying out markup text.
+type textLayout struct {
+	lines                []lineLayout
+	width, height, depth vg.Length // like draw.TextHandler.Box
+}
+
+// lineLayout is one line of a textLayout.
+type lineLayout struct {
+	spans           []placedSpan
+	width           vg.Length
+	ascent, descent vg.Length // relative to the baseline, descent < 0
+	advance         vg.Length // from the baseline of this line to the next
+}
+
+// placedSpan is a text span with its font and offset from the start of
+// the line's baseline.
+type placedSpan struct {
+	text    string
+	font    vg.Font
+	x, rise vg.Length
+}
+
+// layoutMarkup lays out the markup txt in font. Lines are separated by the
+// font size like in draw.PlainTextHandler plus the additional space
+// needed by super- and subscripts.
+func layoutMarkup(txt string, font vg.Font) textLayout {
+	ext := font.Extents()
+	baseVariant := fontVariant(font.Name())
+	var tl textLayout
+	for _, spans := range parseMarkup(txt) {
+		line := lineLayout{ascent: ext.Ascent, descent: ext.Descent}
+		for _, span := range spans {
+			f := spanFont(font, baseVariant|span.variant, font.Size*vg.Length(span.scale), span.text)
+			rise := font.Size * vg.Length(span.rise)
+			line.spans = append(line.spans, placedSpan{span.text, f, line.width, rise})
+			line.width += f.Width(span.text)
+			e := f.Extents()
+			line.ascent = maxLength(line.ascent, rise+e.Ascent)
+			line.descent = minLength(line.descent, rise+e.Descent)
+		}
+		tl.width = maxLength(tl.width, line.width)
+		tl.lines = append(tl.lines, line)
+	}
+
+	tl.height = tl.lines[0].ascent
+	for i := range tl.lines[1:] {
+		this, next := &tl.lines[i], tl.lines[i+1]
+		this.advance = font.Size + (next.ascent - ext.Ascent) + (ext.Descent - this.descent)
+		tl.height += this.advance
+	}
+	tl.depth = -tl.lines[len(tl.lines)-1].descent
+	return tl
+}
+
+// spanFont returns the font for text in the given variant and size
+// derived from base.
+func spanFont(base vg.Font, variant FontVariant, size vg.Length, text string) vg.Font {
+	font := base
+	font.Size = size
+	if family, ok := fontFamilyOf(base.Name()); ok {
+		if f, err := vg.MakeFont(family.Face(variant), size); err == nil {
+			font = f
+		}
+	}
+	return coveringFont(font, text)
+}
+
+// ----------------------------------------------------------------------------
+// Measuring text
+
+// textWidth returns the width of txt drawn with sty's text handler before
+// rotation.
+func textWidth(sty draw.TextStyle, txt string) vg.Length {
+	if sty.Handler == nil {
+		return sty.Width(txt)
+	}
+	width, _, _ := sty.Handler.Box(txt, sty.Font)
+	return width
+}
+
+// textHeight returns the height of txt drawn with sty's text handler before
+// rotation.
+func textHeight(sty draw.TextStyle, txt string) vg.Length {
+	if sty.Handler == nil {
+		return sty.Height(txt)
+	}
+	_, height, depth := sty.Handler.Box(txt, sty.Font)
+	return height + depth
+}
+
+// textRectangle is like sty.Rectangle(txt) but measures txt with sty's
+// text handler.
+func textRectangle(sty draw.TextStyle, txt string) vg.Rectangle {
+	w, h := textWidth(sty, txt), textHeight(sty, txt)
+	xoff, yoff := vg.Length(sty.XAlign)*w, vg.Length(sty.YAlign)*h
+	sin, cos := math.Sincos(sty.Rotation)
+	r := vg.Rectangle{
+		Min: vg.Point{X: vg.Length(math.Inf(1)), Y: vg.Length(math.Inf(1))},
+		Max: vg.Point{X: vg.Length(math.Inf(-1)), Y: vg.Length(math.Inf(-1))},
+	}
+	for _, p := range []vg.Point{{X: xoff, Y: yoff}, {X: xoff, Y: h + yoff},
+		{X: w + xoff, Y: yoff}, {X: w + xoff, Y: h + yoff}} {
+		q := vg.Point{
+			X: p.X*vg.Length(cos) - p.Y*vg.Length(sin),
+			Y: p.X*vg.Length(sin) + p.Y*vg.Length(cos),
+		}
+		r.Min.X, r.Min.Y = minLength(r.Min.X, q.X), minLength(r.Min.Y, q.Y)
+		r.Max.X, r.Max.Y = maxLength(r.Max.X, q.X), maxLength(r.Max.Y, q.Y)
+	}
+	return r
+}
+
+// fitText returns size enlarged by the amount txt drawn with sty is higher
+// than a single line of text. It is used to grow the space reserved for
+// titles and strips for multi-line text and text with super- and
+// subscripts.
+func fitText(size vg.Length, sty draw.TextStyle, txt string) vg.Length {
+	if extra := textHeight(sty, txt) - textHeight(sty, "M"); extra > 0 {
+		return size + extra
+	}
+	return size
+}
@@ -0,0 +1,245 @@
+package facet
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/recorder"
+)
+
+// spansString formats lines of spans compactly: Super- and subscripts
+// are marked with ^ and _ and bold and italic variants with * and /.
+func spansString(lines [][]textSpan) string {
+	parts := []string{}
+	for _, spans := range lines {
+		line := ""
+		for _, s := range spans {
+			mark := ""
+			switch {
+			case s.rise > 0:
+				mark = "^"
+			case s.rise < 0:
+				mark = "_"
+			}
+			if s.variant&Bold != 0 {
+				mark += "*"
+			}
+			if s.variant&Italic != 0 {
+				mark += "/"
+			}
+			line += fmt.Sprintf("[%s%s]", mark, s.text)
+		}
+		parts = append(parts, line)
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestParseMarkup(t *testing.T) {
+	for i, tc := range []struct {
+		markup string
+		want   string
+	}{
+		{"plain", "[plain]"},
+		{"m^2", "[m][^2]"},
+		{"CO_2 level", "[CO][_2][ level]"},
+		{"x^{n+1}", "[x][^n+1]"},
+		{`µs^{-1}`, "[µs][^-1]"},
+		{`e^{x_i}`, "[e][^x][^i]"},
+		{`\b{bold} and \i{italic}`, "[*bold][ and ][/italic]"},
+		{`\b{bold \i{both}}`, "[*bold ][*/both]"},
+		{`\Delta t_{max}`, "[Δ t][_max]"},
+		{`\alpha\beta\omega`, "[αβω]"},
+		{`a\_b \^ \{\} \\`, `[a_b ^ {} \]`},
+		{`\unknown{x}`, `[\unknown][x]`},
+		{"line 1\nline 2\n", "[line 1]|[line 2]"},
+		{"a^", "[a^]"},
+		{"a}", "[a}]"},
+		{"x_{unclosed", "[x][_unclosed]"},
+	} {
+		if got := spansString(parseMarkup(tc.markup)); got != tc.want {
+			t.Errorf("%d. parseMarkup(%q)=%s, want %s", i, tc.markup, got, tc.want)
+		}
+	}
+}
+
+func TestMarkupTextHandler(t *testing.T) {
+	font, _ := MakeFont("Helvetica", Regular, 12)
+	plain := draw.TextStyle{Font: font}
+	markup := draw.TextStyle{Font: font, Handler: MarkupTextHandler{}}
+
+	// Text without markup is measured like plain text.
+	for _, txt := range []string{"Hello", "two\nlines"} {
+		if w, pw := textWidth(markup, txt), plain.Width(txt); w != pw {
+			t.Errorf("%q: width %v, want %v", txt, w, pw)
+		}
+		if h, ph := textHeight(markup, txt), plain.Height(txt); h != ph {
+			t.Errorf("%q: height %v, want %v", txt, h, ph)
+		}
+	}
+
+	// Scripts make the text higher and are narrower than regular text.
+	single := textHeight(markup, "x")
+	for _, txt := range []string{"x^2", "x_2", "x^{y^2}", "x\ny"} {
+		if h := textHeight(markup, txt); h <= single {
+			t.Errorf("%q: height %v not higher than %v", txt, h, single)
+		}
+	}
+	if w, pw := textWidth(markup, "x^2"), plain.Width("x2"); w >= pw {
+		t.Errorf("x^2: width %v not smaller than %v", w, pw)
+	}
+	if h1, h2 := textHeight(markup, "x^{y^2}"), textHeight(markup, "x^y"); h1 <= h2 {
+		t.Errorf("nested superscript %v not higher than %v", h1, h2)
+	}
+
+	// Markup must not show up in the width.
+	if w, pw := textWidth(markup, `\b{ab}`), textWidth(markup, "ab"); w > pw*1.2 {
+		t.Errorf(`\b{ab}: width %v, want about %v`, w, pw)
+	}
+}
+
+func TestFitText(t *testing.T) {
+	font, _ := MakeFont("Helvetica", Bold, 12)
+	sty := draw.TextStyle{Font: font, Handler: MarkupTextHandler{}}
+	size := vg.Length(24)
+	if got := fitText(size, sty, "Title"); got != size {
+		t.Errorf("single line: got %v, want %v", got, size)
+	}
+	if got := fitText(size, sty, ""); got != size {
+		t.Errorf("empty: got %v, want %v", got, size)
+	}
+	if got := fitText(size, sty, "Two\nlines"); got < size+font.Size {
+		t.Errorf("two lines: got %v, want at least %v", got, size+font.Size)
+	}
+	if got := fitText(size, sty, "m^2"); got <= size {
+		t.Errorf("superscript: got %v, want more than %v", got, size)
+	}
+}
+
+func TestTextRectangle(t *testing.T) {
+	font, _ := MakeFont("Helvetica", Regular, 12)
+	for _, sty := range []draw.TextStyle{
+		{Font: font},
+		{Font: font, XAlign: draw.XCenter, YAlign: draw.YTop},
+		{Font: font, XAlign: draw.XRight, Rotation: 0.7},
+		{Font: font, Rotation: -1.5707963267948966, Handler: FallbackTextHandler{}},
+	} {
+		got, want := textRectangle(sty, "Label"), sty.Rectangle("Label")
+		if !closeRect(got, want) {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+	}
+}
+
+func closeRect(a, b vg.Rectangle) bool {
+	close := func(x, y vg.Length) bool { return x-y < 1e-6 && y-x < 1e-6 }
+	return close(a.Min.X, b.Min.X) && close(a.Min.Y, b.Min.Y) &&
+		close(a.Max.X, b.Max.X) && close(a.Max.Y, b.Max.Y)
+}
+
+func TestPlainTitles(t *testing.T) {
+	titles := func(p *Plot) {
+		p.Title = "cpu_usage"
+		p.XScales[0].Title = `C:\temp`
+		p.YScales[0].Title = "x^y {z}"
+	}
+
+	p := axisTestPlot(1, 1)
+	titles(p)
+	rec := drawAxisTestPlot(t, p)
+	for _, title := range []string{"cpu_usage", `C:\temp`, "x^y {z}"} {
+		if n := len(drawnStrings(rec, title)); n != 1 {
+			t.Errorf("%q drawn %d times, want once", title, n)
+		}
+	}
+
+	p = axisTestPlot(1, 1)
+	MarkupTheme(&p.Style)
+	titles(p)
+	rec = drawAxisTestPlot(t, p)
+	for _, span := range []string{"cpu", "u", "sage", `C:\temp`, "x", "y", "z"} {
+		if len(drawnStrings(rec, span)) == 0 {
+			t.Errorf("markup span %q not drawn", span)
+		}
+	}
+}
+
+func TestMarkupStripsAndTicks(t *testing.T) {
+	p := axisTestPlot(1, 1)
+	MarkupTheme(&p.Style)
+	p.ColLabels[0], p.RowLabels[0] = "CO_2", "m^4"
+	p.XScales[0].Ticker = plot.ConstantTicks{{Value: 3, Label: "10^3"}}
+	rec := drawAxisTestPlot(t, p)
+
+	spans := map[string]*recorder.FillString{}
+	for _, a := range rec.Actions {
+		if fs, ok := a.(*recorder.FillString); ok {
+			spans[fs.String] = fs
+		}
+	}
+	for _, markup := range []string{"CO_2", "m^4", "10^3"} {
+		if spans[markup] != nil {
+			t.Errorf("%q drawn literally", markup)
+		}
+	}
+	for _, tc := range []struct {
+		base, script string
+		up           bool
+	}{
+		{"CO", "2", false}, // subscript in the horizontal strip
+		{"10", "3", true},  // superscript in the tick label
+	} {
+		base, script := spans[tc.base], spans[tc.script]
+		if base == nil || script == nil {
+			t.Errorf("%q or %q not drawn", tc.base, tc.script)
+			continue
+		}
+		if script.Size >= base.Size || (script.Point.Y > base.Point.Y) != tc.up {
+			t.Errorf("%q at %v size %v relative to %q at %v size %v", tc.script,
+				script.Point, script.Size, tc.base, base.Point, base.Size)
+		}
+	}
+	if spans["m"] == nil || spans["4"] == nil {
+		t.Errorf("vertical strip label not split")
+	}
+}
+
+func TestStripSize(t *testing.T) {
+	for _, tc := range []struct {
+		label  string
+		markup bool
+	}{
+		{"a", false},
+		{"two\nlines", false},
+		{"µs^{-1}", false},
+		{"µs^{-1}", true},
+	} {
+		p := axisTestPlot(1, 1)
+		if tc.markup {
+			p.Style.HStrip.Handler = MarkupTextHandler{}
+			p.Style.VStrip.Handler = MarkupTextHandler{}
+		}
+		p.ColLabels[0], p.RowLabels[0] = tc.label, tc.label
+		drawAxisTestPlot(t, p)
+		r := p.Panels[0][0].Canvas.Rectangle
+
+		height := fitText(p.Style.HStrip.Height, p.Style.HStrip.TextStyle, tc.label)
+		if got := 300 - r.Max.Y; math.Abs(float64(got-height)) > 1e-6 {
+			t.Errorf("%q markup=%t: horizontal strip height %v, want %v",
+				tc.label, tc.markup, got, height)
+		}
+		width := fitText(p.Style.VStrip.Width, p.Style.VStrip.TextStyle, tc.label)
+		if got := 400 - r.Max.X; math.Abs(float64(got-width)) > 1e-6 {
+			t.Errorf("%q markup=%t: vertical strip width %v, want %v",
+				tc.label, tc.markup, got, width)
+		}
+		if grows := tc.label == "two\nlines" || tc.markup; grows != (height > p.Style.HStrip.Height) {
+			t.Errorf("%q markup=%t: strip height %v, base height %v",
+				tc.label, tc.markup, height, p.Style.HStrip.Height)
+		}
+	}
+}
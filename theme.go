@@ -0,0 +1,168 @@
+package facet
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ----------------------------------------------------------------------------
+// Themes
+
+// A Theme modifies a Style, e.g. by changing the panel background and the
+// grid lines. Themes are applied on top of DefaultFacetStyle by NewStyle.
+// A Theme may be derived from an other one with Extend:
+//     myTheme := BWTheme.Extend(func(s *Style) {
+//         s.Panel.Border.Width = 2
+//     })
+type Theme func(s *Style)
+
+// Extend returns the Theme which applies t followed by the overrides.
+func (t Theme) Extend(overrides ...Theme) Theme {
+	return func(s *Style) {
+		if t != nil {
+			t(s)
+		}
+		for _, o := range overrides {
+			if o != nil {
+				o(s)
+			}
+		}
+	}
+}
+
+// NewStyle returns the DefaultFacetStyle for the given baseFontSize
+// modified by the themes in order.
+func NewStyle(baseFontSize vg.Length, themes ...Theme) Style {
+	s := DefaultFacetStyle(baseFontSize)
+	Theme(nil).Extend(themes...)(&s)
+	return s
+}
+
+// GreyTheme is the default look of DefaultFacetStyle which mimics ggplot2's
+// theme_grey: A grey panel background with white grid lines.
+var GreyTheme Theme = func(s *Style) {}
+
+// BWTheme mimics ggplot2's theme_bw: White panels with light grey grid
+// lines and a dark border.
+var BWTheme Theme = func(s *Style) {
+	s.Panel.Background = color.White
+	s.Panel.Border = draw.LineStyle{Color: color.Gray{0x33}, Width: 1}
+	s.Grid.Major.Color = color.Gray{0xeb}
+	s.Grid.Minor.Color = color.Gray{0xeb}
+	s.HStrip.Background = color.Gray{0xd9}
+	s.VStrip.Background = color.Gray{0xd9}
+	s.Legend.Discrete.Background = color.White
+}
+
+// MinimalTheme mimics ggplot2's theme_minimal: No backgrounds, borders or
+// ticks, only light grey grid lines.
+var MinimalTheme Theme = BWTheme.Extend(func(s *Style) {
+	s.Panel.Background = color.Transparent
+	s.Panel.Border = draw.LineStyle{}
+	s.HStrip.Background = color.Transparent
+	s.VStrip.Background = color.Transparent
+	s.Legend.Discrete.Background = color.Transparent
+	s.XAxis.MajorTick.Color, s.XAxis.MinorTick.Color = nil, nil
+	s.YAxis.MajorTick.Color, s.YAxis.MinorTick.Color = nil, nil
+})
+
+// ClassicTheme mimics ggplot2's theme_classic: White panels with black
+// axis lines and no grid lines.
+var ClassicTheme Theme = func(s *Style) {
+	s.Panel.Background = color.White
+	s.Grid.Major.Color, s.Grid.Minor.Color = nil, nil
+	s.XAxis.Line = draw.LineStyle{Color: color.Black, Width: 1}
+	s.YAxis.Line = draw.LineStyle{Color: color.Black, Width: 1}
+	s.XAxis.MajorTick.Color = color.Black
+	s.YAxis.MajorTick.Color = color.Black
+	s.HStrip.Background = color.White
+	s.VStrip.Background = color.White
+	s.Legend.Discrete.Background = color.White
+}
+
+// DarkTheme mimics ggplot2's theme_dark: Dark grey panels with slightly
+// lighter grid lines and dark strips, suitable for bright colors.
+var DarkTheme Theme = func(s *Style) {
+	s.Panel.Background = color.Gray{0x7f}
+	s.Grid.Major.Color = color.Gray{0x6b}
+	s.Grid.Minor.Color = color.Gray{0x6b}
+	s.HStrip.Background = color.Gray{0x26}
+	s.HStrip.Color = color.Gray{0xe5}
+	s.VStrip.Background = color.Gray{0x26}
+	s.VStrip.Color = color.Gray{0xe5}
+	s.Legend.Discrete.Background = color.Gray{0x7f}
+}
+
+// PrintTheme is suitable for print and publications: Black on white with
+// a black border, black ticks, only major grid lines and white strips.
+var PrintTheme Theme = func(s *Style) {
+	s.Background = color.White
+	s.Panel.Background = color.White
+	s.Panel.Border = draw.LineStyle{Color: color.Black, Width: 1}
+	s.Grid.Major.Color = color.Gray{0xd9}
+	s.Grid.Major.Width = 0.5
+	s.Grid.Minor.Color = nil
+	s.XAxis.MajorTick.Color = color.Black
+	s.YAxis.MajorTick.Color = color.Black
+	s.HStrip.Background = color.White
+	s.VStrip.Background = color.White
+	s.Legend.Discrete.Background = color.White
+	s.GeomDefault.Color = color.Black
+	s.GeomDefault.FillColor = color.Gray{0x59}
+}
+
+// MarkupTheme enables markup like "m^2" or "CO_2" (see MarkupTextHandler)
+// in all text: titles, axis titles, strip labels, tick labels and legend
+// labels. It does not change the look and can be combined with the other
+// themes:
+//     NewStyle(12, BWTheme, MarkupTheme)
+// Labels stemming from the data must escape the special characters, e.g.
+// "cpu\\_usage", once markup is enabled.
+var MarkupTheme Theme = func(s *Style) {
+	for _, sty := range textStyles(reflect.ValueOf(s).Elem()) {
+		sty.Handler = MarkupTextHandler{}
+	}
+}
+
+// namedThemes is the registry of named themes.
+var namedThemes = map[string]Theme{
+	"grey":    GreyTheme,
+	"bw":      BWTheme,
+	"minimal": MinimalTheme,
+	"classic": ClassicTheme,
+	"dark":    DarkTheme,
+	"print":   PrintTheme,
+}
+
+// RegisterTheme makes t available under the given name to ThemeByName.
+// Names are case insensitive; an existing theme of the same name is
+// replaced.
+func RegisterTheme(name string, t Theme) {
+	namedThemes[strings.ToLower(name)] = t
+}
+
+// ThemeByName returns the registered theme name, e.g. "bw", "minimal",
+// "classic", "dark" or "print".
+func ThemeByName(name string) (Theme, error) {
+	t, ok := namedThemes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("facet: unknown theme %q", name)
+	}
+	return t, nil
+}
+
+// ThemeNames returns the sorted names of all registered themes.
+func ThemeNames() []string {
+	names := make([]string, 0, len(namedThemes))
+	for name := range namedThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
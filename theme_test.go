@@ -0,0 +1,58 @@
+package facet
+
+import (
+	"image/color"
+	"testing"
+
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+func TestThemeExtend(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	theme := BWTheme.Extend(func(s *Style) {
+		s.Panel.Border.Color = red
+	})
+	s := NewStyle(12, theme)
+	if s.Panel.Border.Color != red {
+		t.Errorf("override not applied: border color %v", s.Panel.Border.Color)
+	}
+	if s.Panel.Background != color.White {
+		t.Errorf("base theme not applied: panel background %v", s.Panel.Background)
+	}
+	if s.Panel.Border.Width != 1 {
+		t.Errorf("base theme lost: border width %v", s.Panel.Border.Width)
+	}
+
+	// Later themes win.
+	s = NewStyle(12, DarkTheme, ClassicTheme)
+	if s.Panel.Background != color.White {
+		t.Errorf("got panel background %v, want white", s.Panel.Background)
+	}
+}
+
+func TestThemeByName(t *testing.T) {
+	for _, name := range ThemeNames() {
+		theme, err := ThemeByName(name)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", name, err)
+			continue
+		}
+
+		p := NewSimplePlot()
+		p.Style = NewStyle(12, theme)
+		p.XScales[0].UpdateData(Interval{1, 2})
+		p.YScales[0].UpdateData(Interval{1, 3})
+		p.Prepare()
+		if err := p.Draw(draw.New(vgimg.New(200, 150))); err != nil {
+			t.Errorf("%s: draw failed: %s", name, err)
+		}
+	}
+
+	if _, err := ThemeByName("BW"); err != nil {
+		t.Errorf("theme names are case insensitive: %s", err)
+	}
+	if _, err := ThemeByName("fancy"); err == nil {
+		t.Errorf("missing error for unknown theme")
+	}
+}
@@ -7,8 +7,11 @@ package facet
 import (
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
 )
 
 // DefaultTicks is suitable for the Tick.Marker field of an Axis,
@@ -19,13 +22,18 @@ var _ plot.Ticker = DefaultTicks(0)
 
 // Ticks returns Ticks in the specified range.
 func (dt DefaultTicks) Ticks(min, max float64) []plot.Tick {
+	return niceTicks(min, max, int(dt), nil)
+}
+
+// niceTicks returns about want major ticks and the minor ticks in the
+// range [min, max] as determined by talbotLinHanrahan with the given
+// legibility function (which may be nil).
+func niceTicks(min, max float64, want int, legibility func(lMin, lMax, lStep float64) float64) []plot.Tick {
 	if max <= min {
 		panic("illegal range")
 	}
 
-	suggestedTicks := int(dt)
-
-	labels, step, q, mag := talbotLinHanrahan(min, max, suggestedTicks, withinData, nil, nil, nil)
+	labels, step, q, mag := talbotLinHanrahan(min, max, want, withinData, nil, nil, legibility)
 	majorDelta := step * math.Pow10(mag)
 	if q == 0 {
 		// Simple fall back was chosen, so
@@ -33,18 +41,7 @@ func (dt DefaultTicks) Ticks(min, max float64) []plot.Tick {
 		majorDelta = labels[1] - labels[0]
 	}
 
-	// Choose a reasonable, but ad
-	// hoc formatting for labels.
-	fc := byte('f')
-	var off int
-	if mag < -1 || 6 < mag {
-		off = 1
-		fc = 'g'
-	}
-	if math.Trunc(q) != q {
-		off += 2
-	}
-	prec := minInt(6, maxInt(off, -mag))
+	fc, prec := labelFormat(q, mag)
 	var ticks []plot.Tick
 	for _, v := range labels {
 		ticks = append(ticks, plot.Tick{Value: v, Label: strconv.FormatFloat(v, fc, prec, 64)})
@@ -93,6 +90,21 @@ func (dt DefaultTicks) Ticks(min, max float64) []plot.Tick {
 	return ticks
 }
 
+// labelFormat chooses a reasonable, but ad hoc formatting for labels
+// q·10^mag apart: The format and precision for strconv.FormatFloat.
+func labelFormat(q float64, mag int) (fc byte, prec int) {
+	fc = 'f'
+	var off int
+	if mag < -1 || 6 < mag {
+		off = 1
+		fc = 'g'
+	}
+	if math.Trunc(q) != q {
+		off += 2
+	}
+	return fc, minInt(6, maxInt(off, -mag))
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -106,3 +118,510 @@ func maxInt(a, b int) int {
 	}
 	return b
 }
+
+// SpaceTicks is a Ticker which adapts the number of ticks to the space
+// available on an axis: It uses the Talbot, Lin and Hanrahan algorithm
+// with a legibility score which measures the labels in Font and rejects
+// overlapping and penalizes crowded labels. If not even two labels fit,
+// only the label closest to the center of the range is kept (or none if
+// it is longer than the axis) and the other ticks stay unlabeled. Axes
+// of X and Y scales without an explicit Ticker use SpaceTicks (instead of
+// DefaultTicks) and fill in the unset fields from the panel size and the
+// Style.
+type SpaceTicks struct {
+	// Number is the preferred number of major ticks if space permits.
+	Number int
+
+	// Length is the length of the axis. If zero SpaceTicks works like
+	// DefaultTicks(Number).
+	Length vg.Length
+
+	// Font is the font of the tick labels.
+	Font vg.Font
+
+	// Margin, if positive, is the space beyond each end of the axis the
+	// labels may use, e.g. half the space between two facet panels.
+	// Labels sticking out further are rejected.
+	Margin vg.Length
+
+	// Vertical axes stack their labels: The label heights matter
+	// instead of their widths.
+	Vertical bool
+
+	// Format, if non-nil, formats the labels like Scale.Format.
+	Format func(x float64) string
+}
+
+var _ plot.Ticker = SpaceTicks{}
+
+// Ticks returns Ticks in the specified range.
+func (st SpaceTicks) Ticks(min, max float64) []plot.Tick {
+	number := st.Number
+	if number < 2 {
+		number = 4
+	}
+	if st.Length <= 0 || st.Font.Size <= 0 || max <= min {
+		return niceTicks(min, max, number, nil)
+	}
+
+	// Guess the label size from labels at the ends of the range.
+	step := (max - min) / float64(number)
+	size := math.Max(st.extent(st.label(min, step)), st.extent(st.label(max, step)))
+	spacing := size + 2*float64(st.Font.Size)
+	if st.Vertical {
+		spacing = 3 * size
+	}
+	want := minInt(number, int(float64(st.Length)/spacing))
+	if want < 2 {
+		want = 2
+	}
+
+	legibility := st.legibility(min, max)
+	ticks := niceTicks(min, max, want, legibility)
+	if !st.legible(ticks, legibility) {
+		ticks = st.singleLabel(niceTicks(min, max, 2, nil), min, max)
+	}
+	if st.Format != nil {
+		for i := range ticks {
+			if ticks[i].Label != "" {
+				ticks[i].Label = st.Format(ticks[i].Value)
+			}
+		}
+	}
+	return ticks
+}
+
+// label returns the label of x for major ticks step apart.
+func (st SpaceTicks) label(x, step float64) string {
+	if st.Format != nil {
+		return st.Format(x)
+	}
+	mag := int(math.Floor(math.Log10(step)))
+	q := math.Round(step/math.Pow10(mag)*1e6) / 1e6
+	fc, prec := labelFormat(q, mag)
+	return strconv.FormatFloat(x, fc, prec, 64)
+}
+
+// extent returns the size of label along the axis.
+func (st SpaceTicks) extent(label string) float64 {
+	if st.Vertical {
+		return float64(st.Font.Extents().Height)
+	}
+	return float64(st.Font.Width(label))
+}
+
+// legible reports whether the labels of ticks do not overlap.
+func (st SpaceTicks) legible(ticks []plot.Tick, legibility func(lMin, lMax, lStep float64) float64) bool {
+	var labels []float64
+	for _, t := range ticks {
+		if t.Label != "" {
+			labels = append(labels, t.Value)
+		}
+	}
+	if len(labels) < 2 {
+		return true
+	}
+	n := len(labels) - 1
+	step := (labels[n] - labels[0]) / float64(n)
+	return !math.IsInf(legibility(labels[0], labels[n], step), -1)
+}
+
+// singleLabel keeps only the label of the major tick closest to the
+// center of [min, max] and drops it too if it is longer than the axis.
+// The other major ticks become unlabeled.
+func (st SpaceTicks) singleLabel(ticks []plot.Tick, min, max float64) []plot.Tick {
+	center, best := (min+max)/2, -1
+	for i, t := range ticks {
+		if t.Label != "" && (best == -1 ||
+			math.Abs(t.Value-center) < math.Abs(ticks[best].Value-center)) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return ticks
+	}
+	for i := range ticks {
+		if i != best {
+			ticks[i].Label = ""
+		}
+	}
+	label := ticks[best].Label
+	if st.Format != nil {
+		label = st.Format(ticks[best].Value)
+	}
+	if st.extent(label) > float64(st.Length) {
+		ticks[best].Label = ""
+	}
+	return ticks
+}
+
+// legibility returns the legibility score function for talbotLinHanrahan
+// on an axis showing [min, max]. It averages two of the components from
+// the paper: The format component prefers plain decimal labels to labels
+// in exponential notation. The overlap component rejects overlapping
+// labels and penalizes labels closer than 1.5 em. Labels sticking out of
+// the axis ends by more than a quarter em are penalized too as they may
+// overlap the labels of the neighbouring panel; beyond Margin they are
+// rejected. The font size and the
+// orientation of the labels are fixed by the Style, so the paper's
+// font-size and orientation components are constant and omitted.
+func (st SpaceTicks) legibility(min, max float64) func(lMin, lMax, lStep float64) float64 {
+	em := float64(st.Font.Size)
+	scale := float64(st.Length) / (max - min)
+	return func(lMin, lMax, lStep float64) float64 {
+		n := int(math.Round((lMax-lMin)/lStep)) + 1
+		if n > 1000 {
+			return math.Inf(-1)
+		}
+		format := 1.0
+		dist := lStep * scale
+		gap := math.Inf(1)
+		label := st.label(lMin, lStep)
+		first := st.extent(label)
+		prev := first
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				label = st.label(lMin+float64(i)*lStep, lStep)
+				size := st.extent(label)
+				gap = math.Min(gap, dist-(prev+size)/2)
+				prev = size
+			}
+			if st.Format == nil && strings.Contains(label, "e") {
+				format = 0
+			}
+		}
+		overhang := math.Max(first/2-(lMin-min)*scale,
+			prev/2-(max-lMax)*scale)
+		overlap := 1.0
+		switch {
+		case gap <= 0, st.Margin > 0 && overhang > float64(st.Margin):
+			return math.Inf(-1)
+		case !st.Vertical && overhang > em/4:
+			overlap = -10 * overhang / em
+		case gap < 1.5*em:
+			overlap = 2 - 1.5*em/gap
+		}
+		return (format + overlap) / 2
+	}
+}
+
+// LogTicks is suitable for logarithmic axes: Major ticks are placed at
+// the powers of ten and minor ticks at 2 to 9 times the powers of ten.
+// If the range contains less than two powers of ten the minor ticks at 2
+// and 5 times the powers of ten are labeled too. Non-positive ranges
+// produce no ticks.
+type LogTicks struct {
+	// Format determines the label format: "power" labels 1000 as 10³,
+	// "si" labels 1000 as 1k. Any other value produces plain numbers.
+	Format string
+}
+
+var _ plot.Ticker = LogTicks{}
+
+// Ticks returns Ticks in the specified range.
+func (lt LogTicks) Ticks(min, max float64) []plot.Tick {
+	if min <= 0 || max <= 0 || max < min {
+		return nil
+	}
+
+	first := int(math.Floor(math.Log10(min)))
+	last := int(math.Ceil(math.Log10(max)))
+	majors := 0
+	for e := first; e <= last; e++ {
+		if v := math.Pow10(e); v >= min && v <= max {
+			majors++
+		}
+	}
+
+	var ticks []plot.Tick
+	for e := first; e <= last; e++ {
+		decade := math.Pow10(e)
+		for m := 1; m <= 9; m++ {
+			v := float64(m) * decade
+			if v < min || v > max {
+				continue
+			}
+			tick := plot.Tick{Value: v}
+			if m == 1 || (majors < 2 && (m == 2 || m == 5)) {
+				tick.Label = lt.label(m, e)
+			}
+			ticks = append(ticks, tick)
+		}
+	}
+	return ticks
+}
+
+// label formats m * 10^e.
+func (lt LogTicks) label(m, e int) string {
+	switch lt.Format {
+	case "power":
+		power := "10" + superscript(strconv.Itoa(e))
+		if m == 1 {
+			return power
+		}
+		return strconv.Itoa(m) + "×" + power
+	case "si":
+		return siLabel(float64(m) * math.Pow10(e))
+	}
+	v := float64(m) * math.Pow10(e)
+	if e < -4 || e > 6 {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// superscript returns s with digits and minus signs replaced by their
+// superscript forms.
+func superscript(s string) string {
+	const digits = "⁰¹²³⁴⁵⁶⁷⁸⁹"
+	sup := []rune(digits)
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			out = append(out, sup[r-'0'])
+		case r == '-':
+			out = append(out, '⁻')
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// siPrefixes are the SI prefixes for 10^-24 to 10^24 in steps of 10^3.
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// siLabel formats v with an SI prefix, e.g. 2500 as "2.5k".
+func siLabel(v float64) string {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	k := int(math.Floor(math.Log10(math.Abs(v)) / 3))
+	if k < -8 {
+		k = -8
+	} else if k > 8 {
+		k = 8
+	}
+	// Round to the printed precision first: 999999 is "1M", not "1000k".
+	m, _ := strconv.ParseFloat(strconv.FormatFloat(v/math.Pow10(3*k), 'g', 4, 64), 64)
+	if math.Abs(m) >= 1000 && k < 8 {
+		k++
+		m /= 1000
+	}
+	return strconv.FormatFloat(m, 'g', 4, 64) + siPrefixes[k+8]
+}
+
+// PowerTicks is suitable for logarithmic axes to bases other than 10:
+// Ticks are placed at the (integer) powers of Base, thinned out to at
+// most 8 ticks. Powers of e are labeled like e², all others as plain
+// numbers. Ranges containing less than two powers of Base fall back to
+// DefaultTicks.
+type PowerTicks struct {
+	Base float64
+}
+
+var _ plot.Ticker = PowerTicks{}
+
+// Ticks returns Ticks in the specified range.
+func (pt PowerTicks) Ticks(min, max float64) []plot.Tick {
+	if min <= 0 || max <= min {
+		return nil
+	}
+	logb := math.Log(pt.Base)
+	first := int(math.Ceil(math.Log(min)/logb - 1e-9))
+	last := int(math.Floor(math.Log(max)/logb + 1e-9))
+	if last-first < 1 {
+		return DefaultTicks(4).Ticks(min, max)
+	}
+	step := (last-first)/8 + 1
+
+	var ticks []plot.Tick
+	for e := first; e <= last; e += step {
+		label := strconv.FormatFloat(math.Pow(pt.Base, float64(e)), 'g', 6, 64)
+		if pt.Base == math.E {
+			label = "e" + superscript(strconv.Itoa(e))
+		}
+		ticks = append(ticks, plot.Tick{Value: math.Pow(pt.Base, float64(e)), Label: label})
+	}
+	return ticks
+}
+
+// PseudoLogTicks is suitable for pseudo-logarithmic axes: Ticks are placed
+// at 0 and at the positive and negative powers of ten. Ranges containing
+// less than two such values fall back to DefaultTicks.
+type PseudoLogTicks struct{}
+
+var _ plot.Ticker = PseudoLogTicks{}
+
+// Ticks returns Ticks in the specified range.
+func (PseudoLogTicks) Ticks(min, max float64) []plot.Tick {
+	if max <= min {
+		return nil
+	}
+	values := []float64{}
+	for e := 15; e >= 0; e-- {
+		values = append(values, -math.Pow10(e))
+	}
+	values = append(values, 0)
+	for e := 0; e <= 15; e++ {
+		values = append(values, math.Pow10(e))
+	}
+
+	var ticks []plot.Tick
+	for _, v := range values {
+		if v >= min && v <= max {
+			ticks = append(ticks, plot.Tick{Value: v, Label: strconv.FormatFloat(v, 'g', -1, 64)})
+		}
+	}
+	if len(ticks) < 2 {
+		return DefaultTicks(4).Ticks(min, max)
+	}
+	return ticks
+}
+
+// ProbabilityTicks is suitable for axes showing proportions on a logit or
+// probit scale: Ticks are placed at typical probabilities like 0.01, 0.1,
+// 0.5, 0.9 and 0.99. Ranges containing less than three of them fall back
+// to DefaultTicks.
+type ProbabilityTicks struct{}
+
+var _ plot.Ticker = ProbabilityTicks{}
+
+var probabilities = []float64{0.0001, 0.001, 0.01, 0.05, 0.1, 0.25, 0.5,
+	0.75, 0.9, 0.95, 0.99, 0.999, 0.9999}
+
+// Ticks returns Ticks in the specified range.
+func (ProbabilityTicks) Ticks(min, max float64) []plot.Tick {
+	if max <= min {
+		return nil
+	}
+	var ticks []plot.Tick
+	for _, p := range probabilities {
+		if p >= min && p <= max {
+			ticks = append(ticks, plot.Tick{Value: p, Label: strconv.FormatFloat(p, 'f', -1, 64)})
+		}
+	}
+	if len(ticks) < 3 {
+		return DefaultTicks(4).Ticks(min, max)
+	}
+	return ticks
+}
+
+// DateTicks is suitable for axes showing date/time values given as seconds
+// since the Unix epoch: Ticks are placed at calendar boundaries like full
+// minutes, hours, days, months or years. Up to five ticks are generated.
+type DateTicks struct {
+	// Format is the time.Format layout of the labels. If empty a layout
+	// suitable for the tick distance is used.
+	Format string
+
+	// Location is the time zone of the ticks, UTC if nil.
+	Location *time.Location
+}
+
+var _ plot.Ticker = DateTicks{}
+
+// dateSteps are the possible tick distances of DateTicks. Steps with a
+// zero duration are calendar months.
+var dateSteps = []struct {
+	d      time.Duration
+	months int
+	layout string
+}{
+	{time.Second, 0, "15:04:05"},
+	{5 * time.Second, 0, "15:04:05"},
+	{15 * time.Second, 0, "15:04:05"},
+	{time.Minute, 0, "15:04"},
+	{5 * time.Minute, 0, "15:04"},
+	{15 * time.Minute, 0, "15:04"},
+	{time.Hour, 0, "15:04"},
+	{3 * time.Hour, 0, "Jan 2 15:04"},
+	{6 * time.Hour, 0, "Jan 2 15:04"},
+	{24 * time.Hour, 0, "Jan 2"},
+	{7 * 24 * time.Hour, 0, "Jan 2"},
+	{0, 1, "Jan 2006"},
+	{0, 3, "Jan 2006"},
+	{0, 6, "Jan 2006"},
+	{0, 12, "2006"},
+	{0, 24, "2006"},
+	{0, 60, "2006"},
+	{0, 120, "2006"},
+	{0, 600, "2006"},
+}
+
+// Ticks returns Ticks in the specified range.
+func (dt DateTicks) Ticks(min, max float64) []plot.Tick {
+	if max <= min {
+		return nil
+	}
+	loc := dt.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	unix := func(x float64) time.Time {
+		sec, frac := math.Modf(x)
+		return time.Unix(int64(sec), int64(frac*1e9)).In(loc)
+	}
+	span := time.Duration((max - min) * 1e9)
+
+	step := dateSteps[len(dateSteps)-1]
+	for _, s := range dateSteps {
+		d := s.d
+		if s.months > 0 {
+			d = time.Duration(s.months) * 30 * 24 * time.Hour
+		}
+		if span/d <= 4 {
+			step = s
+			break
+		}
+	}
+	layout := dt.Format
+	if layout == "" {
+		layout = step.layout
+	}
+
+	// Start at the calendar boundary below min.
+	start := unix(min)
+	y, m, d := start.Date()
+	switch {
+	case step.months > 0:
+		m = time.Month((int(m)-1)/step.months*step.months + 1)
+		if step.months > 12 {
+			y = y / (step.months / 12) * (step.months / 12)
+			m = time.January
+		}
+		start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case step.d >= 24*time.Hour:
+		start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if step.d == 7*24*time.Hour {
+			start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7) // Monday
+		}
+	default:
+		hour := start.Hour()
+		if step.d >= time.Hour {
+			hour = 0
+		}
+		start = time.Date(y, m, d, hour, 0, 0, 0, loc)
+		start = start.Add(unix(min).Sub(start).Truncate(step.d))
+	}
+
+	var ticks []plot.Tick
+	for t, i := start, 0; i < 1000; i++ {
+		v := float64(t.UnixNano()) / 1e9
+		if v > max {
+			break
+		}
+		if v >= min {
+			ticks = append(ticks, plot.Tick{Value: v, Label: t.Format(layout)})
+		}
+		if step.months > 0 {
+			t = t.AddDate(0, step.months, 0)
+		} else if step.d >= 24*time.Hour {
+			t = t.AddDate(0, 0, int(step.d/(24*time.Hour)))
+		} else {
+			t = t.Add(step.d)
+		}
+	}
+	return ticks
+}
@@ -4,13 +4,20 @@
 package facet
 
 import (
+	"fmt"
 	"math"
+	"sort"
+	"strings"
 
 	"gonum.org/v1/plot"
 )
 
 // A Transformation bundles two functions Trans and Inverse together with
-// an appropiate Ticker. The two functions map two intervals.
+// an appropiate Ticker. The two functions map two intervals: Trans maps x
+// from the data interval from to the interval to and Inverse maps y from
+// the interval from back to the data interval to, i.e.
+//     Inverse(to, from, Trans(from, to, x)) == x
+// This is why Inverse of LinearTrans is the same formula as its Trans.
 type Transformation struct {
 	Name    string
 	Trans   func(from, to Interval, x float64) float64
@@ -71,6 +78,9 @@ var SqrtTransFix0 = Transformation{
 	Ticker: DefaultTicks(5),
 }
 
+// Log10Trans implements a logarithmic mapping from from to to which
+// requires from to be positive. It is the default Trans of Logarithmic
+// scales.
 var Log10Trans = Transformation{
 	Name: "Log10",
 	Trans: func(from, to Interval, x float64) float64 {
@@ -81,5 +91,166 @@ var Log10Trans = Transformation{
 	Inverse: func(from, to Interval, y float64) float64 {
 		return to.Min * math.Pow(10, math.Log10(to.Max/to.Min)*(y-from.Min)/(from.Max-from.Min))
 	},
-	Ticker: plot.LogTicks{},
+	Ticker: LogTicks{},
+}
+
+// functionTrans returns a Transformation which applies the strictly
+// monotonic function f to the data and maps the result linearly.
+// The inverse of f must be given as inv. Decreasing functions like 1/x
+// reverse the direction of the mapping.
+func functionTrans(name string, f, inv func(float64) float64, ticker plot.Ticker) Transformation {
+	image := func(i Interval) Interval {
+		a, b := f(i.Min), f(i.Max)
+		return Interval{math.Min(a, b), math.Max(a, b)}
+	}
+	return Transformation{
+		Name: name,
+		Trans: func(from, to Interval, x float64) float64 {
+			return LinearTrans.Trans(image(from), to, f(x))
+		},
+		Inverse: func(from, to Interval, y float64) float64 {
+			return inv(LinearTrans.Inverse(from, image(to), y))
+		},
+		Ticker: ticker,
+	}
+}
+
+// ReverseTrans implements a linear mapping which maps from.Min to to.Max
+// and from.Max to to.Min, e.g. to draw high values at the bottom.
+var ReverseTrans = functionTrans("Reverse",
+	func(x float64) float64 { return -x },
+	func(y float64) float64 { return -y },
+	DefaultTicks(4))
+
+// Log2Trans implements a logarithmic mapping to base 2. Like Log10Trans
+// it requires positive data.
+var Log2Trans = functionTrans("Log2", math.Log2, math.Exp2, PowerTicks{Base: 2})
+
+// LnTrans implements a logarithmic mapping to base e. Like Log10Trans it
+// requires positive data.
+var LnTrans = functionTrans("Ln", math.Log, math.Exp, PowerTicks{Base: math.E})
+
+// PseudoLogTrans implements the pseudo-logarithmic mapping asinh(x/2)
+// which is linear around 0 and logarithmic for large absolute values.
+// Unlike Log10Trans it handles zero and negative values.
+var PseudoLogTrans = functionTrans("PseudoLog",
+	func(x float64) float64 { return math.Asinh(x / 2) },
+	func(y float64) float64 { return 2 * math.Sinh(y) },
+	PseudoLogTicks{})
+
+// ReciprocalTrans implements the mapping 1/x which reverses the direction
+// like ReverseTrans. The data must not contain 0.
+var ReciprocalTrans = functionTrans("Reciprocal",
+	func(x float64) float64 { return 1 / x },
+	func(y float64) float64 { return 1 / y },
+	DefaultTicks(4))
+
+// LogitTrans implements the logit mapping log(p/(1-p)) suitable for
+// proportions p in the open interval (0,1).
+var LogitTrans = functionTrans("Logit",
+	func(p float64) float64 { return math.Log(p / (1 - p)) },
+	func(y float64) float64 { return 1 / (1 + math.Exp(-y)) },
+	ProbabilityTicks{})
+
+// ProbitTrans implements the probit mapping, the quantile function of the
+// standard normal distribution, suitable for proportions in the open
+// interval (0,1).
+var ProbitTrans = functionTrans("Probit",
+	func(p float64) float64 { return math.Sqrt2 * math.Erfinv(2*p-1) },
+	func(y float64) float64 { return (1 + math.Erf(y/math.Sqrt2)) / 2 },
+	ProbabilityTicks{})
+
+// ExpTrans implements the exponential mapping e^x.
+var ExpTrans = functionTrans("Exp", math.Exp, math.Log, DefaultTicks(4))
+
+// DateTrans implements a linear mapping of date/time values given as
+// seconds since the Unix epoch. Its Ticker places ticks at calendar
+// boundaries like full hours, days, months or years.
+var DateTrans = Transformation{
+	Name:    "Date",
+	Trans:   LinearTrans.Trans,
+	Inverse: LinearTrans.Inverse,
+	Ticker:  DateTicks{},
+}
+
+// Compose returns the Transformation which first applies inner and then
+// outer, e.g. Compose(Log10Trans, ReverseTrans) for a logarithmic scale
+// with high values at the bottom. The inner transformation maps the data
+// interval onto itself so that outer sees values from the data interval.
+// The Ticker of inner is used unless it is a DefaultTicks in which case
+// the Ticker of outer is used.
+func Compose(inner, outer Transformation) Transformation {
+	ticker := inner.Ticker
+	if _, ok := ticker.(DefaultTicks); ok || ticker == nil {
+		ticker = outer.Ticker
+	}
+	return Transformation{
+		Name: inner.Name + "+" + outer.Name,
+		Trans: func(from, to Interval, x float64) float64 {
+			return outer.Trans(from, to, inner.Trans(from, from, x))
+		},
+		Inverse: func(from, to Interval, y float64) float64 {
+			return inner.Inverse(to, to, outer.Inverse(from, to, y))
+		},
+		Ticker: ticker,
+	}
+}
+
+// transformations is the registry of named transformations.
+var transformations = map[string]Transformation{
+	"identity":   IdentityTrans,
+	"linear":     LinearTrans,
+	"sqrt":       SqrtTrans,
+	"sqrt0":      SqrtTransFix0,
+	"log10":      Log10Trans,
+	"log2":       Log2Trans,
+	"ln":         LnTrans,
+	"pseudolog":  PseudoLogTrans,
+	"reciprocal": ReciprocalTrans,
+	"logit":      LogitTrans,
+	"probit":     ProbitTrans,
+	"exp":        ExpTrans,
+	"reverse":    ReverseTrans,
+	"date":       DateTrans,
+}
+
+// RegisterTransformation makes t available under the given name to
+// TransformationByName. Names are case insensitive and must not contain
+// a "+". An existing transformation of the same name is replaced.
+func RegisterTransformation(name string, t Transformation) {
+	if strings.Contains(name, "+") {
+		panic(fmt.Sprintf("facet: illegal transformation name %q", name))
+	}
+	transformations[strings.ToLower(name)] = t
+}
+
+// TransformationByName returns the registered transformation name, e.g.
+// "log10", "sqrt", "reverse" or "date". Several names joined by "+" are
+// composed from left to right: "log10+reverse" is
+// Compose(Log10Trans, ReverseTrans).
+func TransformationByName(name string) (Transformation, error) {
+	var t Transformation
+	for i, n := range strings.Split(name, "+") {
+		next, ok := transformations[strings.ToLower(strings.TrimSpace(n))]
+		if !ok {
+			return Transformation{}, fmt.Errorf("facet: unknown transformation %q", n)
+		}
+		if i == 0 {
+			t = next
+		} else {
+			t = Compose(t, next)
+		}
+	}
+	return t, nil
+}
+
+// TransformationNames returns the sorted names of all registered
+// transformations.
+func TransformationNames() []string {
+	names := make([]string, 0, len(transformations))
+	for name := range transformations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -3,7 +3,9 @@ package facet
 import (
 	"fmt"
 	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 var transformationTests = []struct {
@@ -29,6 +31,31 @@ var transformationTests = []struct {
 	{SqrtTransFix0, 10, 20, 3, 4, 0, 0},
 	{SqrtTransFix0, 10, 20, 3, 4, 10, 2 * math.Sqrt2},
 	{SqrtTransFix0, 10, 20, 3, 4, 20, 4},
+
+	{ReverseTrans, 10, 20, 0, 1, 10, 1},
+	{ReverseTrans, 10, 20, 0, 1, 12, 0.8},
+	{ReverseTrans, 10, 20, 0, 1, 20, 0},
+
+	{Log2Trans, 1, 16, 0, 4, 1, 0},
+	{Log2Trans, 1, 16, 0, 4, 8, 3},
+
+	{LnTrans, 1, math.E * math.E, 0, 1, math.E, 0.5},
+
+	{PseudoLogTrans, -10, 10, 0, 1, 0, 0.5},
+	{PseudoLogTrans, -10, 10, 0, 1, -10, 0},
+	{PseudoLogTrans, 0, 200, 0, 1, 20, 0.566},
+
+	{ReciprocalTrans, 1, 4, 0, 1, 2, 1.0 / 3},
+	{ReciprocalTrans, 1, 4, 0, 1, 4, 0},
+
+	{LogitTrans, 0.1, 0.9, 0, 1, 0.5, 0.5},
+	{LogitTrans, 0.01, 0.5, 0, 1, 0.1, 0.522},
+
+	{ProbitTrans, 0.1, 0.9, 0, 1, 0.5, 0.5},
+	{ProbitTrans, 0.025, 0.5, 0, 1, 0.16, 0.49},
+
+	{ExpTrans, 0, 2, 0, 1, 0, 0},
+	{ExpTrans, 0, 2, 0, 1, 1, 0.27},
 }
 
 func equal64(a, b float64) bool {
@@ -51,3 +78,95 @@ func TestTransform(t *testing.T) {
 		})
 	}
 }
+
+var allTransformations = []Transformation{IdentityTrans, LinearTrans, SqrtTrans,
+	SqrtTransFix0, Log10Trans, ReverseTrans, Log2Trans, LnTrans,
+	PseudoLogTrans, ReciprocalTrans, LogitTrans, ProbitTrans, ExpTrans}
+
+func TestTransformInverse(t *testing.T) {
+	data, U := Interval{0.2, 0.8}, Interval{0, 1}
+	for _, trans := range allTransformations {
+		for _, x := range []float64{0.2, 0.3, 0.5, 0.7, 0.8} {
+			y := trans.Trans(data, U, x)
+			if got := trans.Inverse(U, data, y); math.Abs(got-x) > 1e-9 {
+				t.Errorf("%s: Inverse(Trans(%g)) = %g", trans.Name, x, got)
+			}
+		}
+	}
+}
+
+var transTickerTests = []struct {
+	trans    Transformation
+	min, max float64
+	want     string
+}{
+	{Log2Trans, 1, 40, "1 2 4 8 16 32"},
+	{LnTrans, 1, 30, "e⁰ e¹ e² e³"},
+	{PseudoLogTrans, -20, 1000, "-10 -1 0 1 10 100 1000"},
+	{LogitTrans, 0.001, 0.5, "0.001 0.01 0.05 0.1 0.25 0.5"},
+	{ProbitTrans, 0.4, 0.99, "0.5 0.75 0.9 0.95 0.99"},
+}
+
+func TestTransTicker(t *testing.T) {
+	for _, tc := range transTickerTests {
+		labels := []string{}
+		for _, tick := range tc.trans.Ticker.Ticks(tc.min, tc.max) {
+			if tick.Label != "" {
+				labels = append(labels, tick.Label)
+			}
+		}
+		if got := strings.Join(labels, " "); got != tc.want {
+			t.Errorf("%s ticks in [%g,%g] = %q, want %q",
+				tc.trans.Name, tc.min, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	revlog, err := TransformationByName("log10+Reverse")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	data, U := Interval{10, 1000}, Interval{0, 1}
+	for _, tc := range []struct{ x, want float64 }{{10, 1}, {100, 0.5}, {1000, 0}} {
+		y := revlog.Trans(data, U, tc.x)
+		if !equal64(y, tc.want) {
+			t.Errorf("%s.Trans(%g) = %g, want %g", revlog.Name, tc.x, y, tc.want)
+		}
+		if x := revlog.Inverse(U, data, y); !equal64(x, tc.x) {
+			t.Errorf("%s.Inverse(%g) = %g, want %g", revlog.Name, y, x, tc.x)
+		}
+	}
+	if _, ok := revlog.Ticker.(LogTicks); !ok {
+		t.Errorf("%s uses ticker %T", revlog.Name, revlog.Ticker)
+	}
+
+	if _, err := TransformationByName("log10+foo"); err == nil {
+		t.Errorf("missing error for unknown transformation")
+	}
+}
+
+var dateTicksTests = []struct {
+	min, max string
+	want     string
+}{
+	{"2020-03-04T10:07:00Z", "2020-03-04T10:31:00Z", "10:10 10:15 10:20 10:25 10:30"},
+	{"2020-03-04T10:00:00Z", "2020-03-05T02:00:00Z", "Mar 4 12:00 Mar 4 18:00 Mar 5 00:00"},
+	{"2020-03-04T00:00:00Z", "2020-03-08T00:00:00Z", "Mar 4 Mar 5 Mar 6 Mar 7 Mar 8"},
+	{"2019-11-20T00:00:00Z", "2020-06-01T00:00:00Z", "Jan 2020 Apr 2020"},
+	{"2003-05-01T00:00:00Z", "2017-01-01T00:00:00Z", "2005 2010 2015"},
+}
+
+func TestDateTicks(t *testing.T) {
+	for i, tc := range dateTicksTests {
+		min, _ := time.Parse(time.RFC3339, tc.min)
+		max, _ := time.Parse(time.RFC3339, tc.max)
+		labels := []string{}
+		for _, tick := range (DateTicks{}).Ticks(float64(min.Unix()), float64(max.Unix())) {
+			labels = append(labels, tick.Label)
+		}
+		if got := strings.Join(labels, " "); got != tc.want {
+			t.Errorf("%d. got %q, want %q", i, got, tc.want)
+		}
+	}
+}